@@ -0,0 +1,269 @@
+package myqrcode
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// MinModuleContrast is the lowest luminance contrast ratio between the
+// lightest dark module and the darkest light module that ToImage accepts
+// when the symbol is drawn over a background image
+const MinModuleContrast = 2.0
+
+// BackgroundScaling selects how a background image is sized to the output
+type BackgroundScaling int
+
+const (
+	BackgroundFill   BackgroundScaling = iota // Cover the whole image, cropping the overflow
+	BackgroundFit                             // Fit inside the image, BackgroundColor shows around it
+	BackgroundCenter                          // Unscaled and centered
+)
+
+// PlateMode selects a light plate drawn between the background image and the modules
+type PlateMode int
+
+const (
+	PlateNone      PlateMode = iota
+	PlateModules             // A light square behind each light module, quiet zone included
+	PlateQuietZone           // One plate over the whole symbol and its quiet zone
+)
+
+// BackgroundImage places a picture under the symbol
+type BackgroundImage struct {
+	Image   image.Image
+	Scaling BackgroundScaling
+
+	Plate      PlateMode
+	PlateColor color.Color // Defaults to 80% opaque white, or black for inverted symbols
+	PlateSize  float64     // Share of a module covered by PlateModules plates (default 0.8)
+}
+
+func (b *BackgroundImage) plateColor(inverted bool) color.Color {
+	if b.PlateColor != nil {
+		return b.PlateColor
+	}
+	if inverted {
+		return color.NRGBA{0, 0, 0, 204}
+	}
+	return color.NRGBA{255, 255, 255, 204}
+}
+
+// drawBackgroundImage draws the picture over the whole of img
+func drawBackgroundImage(img *image.RGBA, bg *BackgroundImage) {
+	if bg.Image == nil {
+		return
+	}
+
+	dst := img.Bounds()
+	src := bg.Image.Bounds()
+	w, h := float64(src.Dx()), float64(src.Dy())
+	if w == 0 || h == 0 {
+		return
+	}
+
+	scale := 1.0
+	switch bg.Scaling {
+	case BackgroundFill:
+		scale = max(float64(dst.Dx())/w, float64(dst.Dy())/h)
+	case BackgroundFit:
+		scale = min(float64(dst.Dx())/w, float64(dst.Dy())/h)
+	}
+
+	sw, sh := int(w*scale+0.5), int(h*scale+0.5)
+	x := dst.Min.X + (dst.Dx()-sw)/2
+	y := dst.Min.Y + (dst.Dy()-sh)/2
+	target := image.Rect(x, y, x+sw, y+sh)
+
+	if bg.Scaling == BackgroundCenter {
+		draw.Draw(img, target, bg.Image, src.Min, draw.Over)
+		return
+	}
+	xdraw.CatmullRom.Scale(img, target, bg.Image, src, xdraw.Over, nil)
+}
+
+// drawPlate lightens the background behind the symbol
+func (qr *QRCode) drawPlate(img *image.RGBA, bg *BackgroundImage, moduleSize, quietZone int, inverted bool) {
+	plate := &image.Uniform{bg.plateColor(inverted)}
+
+	switch bg.Plate {
+	case PlateQuietZone:
+		draw.Draw(img, img.Bounds(), plate, image.Point{}, draw.Over)
+
+	case PlateModules:
+		ratio := bg.PlateSize
+		if ratio <= 0 || ratio > 1 {
+			ratio = 0.8
+		}
+		inset := int(float64(moduleSize)*(1-ratio)/2 + 0.5)
+
+		q := quietModules(moduleSize, quietZone)
+		for y := -q; y < qr.Size+q; y++ {
+			for x := -q; x < qr.Size+q; x++ {
+				if qr.isDarkModule(x, y) {
+					continue
+				}
+				imgX := quietZone + x*moduleSize
+				imgY := quietZone + y*moduleSize
+				rect := image.Rect(imgX+inset, imgY+inset, imgX+moduleSize-inset, imgY+moduleSize-inset)
+				draw.Draw(img, rect.Intersect(img.Bounds()), plate, image.Point{}, draw.Over)
+			}
+		}
+	}
+}
+
+// quietModules returns how many module rows the quiet zone spans, counting
+// a partial row when the quiet zone isn't a whole number of modules
+func quietModules(moduleSize, quietZone int) int {
+	return (quietZone + moduleSize - 1) / moduleSize
+}
+
+// moduleArea returns the pixels of module (x, y), which lies in the quiet
+// zone when outside the symbol, clipped to the image of a size x size
+// symbol. Modules in a partial quiet zone row come out narrower.
+func moduleArea(x, y, size, moduleSize, quietZone int) image.Rectangle {
+	imgSize := size*moduleSize + 2*quietZone
+	imgX := quietZone + x*moduleSize
+	imgY := quietZone + y*moduleSize
+	return image.Rect(imgX, imgY, imgX+moduleSize, imgY+moduleSize).Intersect(image.Rect(0, 0, imgSize, imgSize))
+}
+
+// isDarkModule reports whether the module at (x, y) is dark; positions
+// outside the symbol are quiet zone and always light
+func (qr *QRCode) isDarkModule(x, y int) bool {
+	return x >= 0 && x < qr.Size && y >= 0 && y < qr.Size && qr.Matrix[y][x]
+}
+
+// ModuleLuminance returns the relative luminance a scanner sees at the center
+// of every module of a rendered symbol, indexed [row][col]
+func (qr *QRCode) ModuleLuminance(img image.Image, moduleSize, quietZone int) [][]float64 {
+	lum := make([][]float64, qr.Size)
+	for y := 0; y < qr.Size; y++ {
+		lum[y] = make([]float64, qr.Size)
+		for x := 0; x < qr.Size; x++ {
+			lum[y][x] = sampleLuminance(img, quietZone+x*moduleSize, quietZone+y*moduleSize, moduleSize)
+		}
+	}
+	return lum
+}
+
+// sampleLuminance averages the middle third of the module whose top-left
+// corner is at (x, y), over white where the image is transparent
+func sampleLuminance(img image.Image, x, y, moduleSize int) float64 {
+	return sampleArea(img, image.Rect(x, y, x+moduleSize, y+moduleSize))
+}
+
+// sampleArea averages the middle third of r, relative to the image origin,
+// over white where the image is transparent
+func sampleArea(img image.Image, r image.Rectangle) float64 {
+	w, h := max(1, r.Dx()/3), max(1, r.Dy()/3)
+	offsetX, offsetY := (r.Dx()-w)/2, (r.Dy()-h)/2
+	origin := img.Bounds().Min.Add(r.Min)
+
+	var sr, sg, sb, n uint64
+	for py := 0; py < h; py++ {
+		for px := 0; px < w; px++ {
+			cr, cg, cb, ca := img.At(origin.X+offsetX+px, origin.Y+offsetY+py).RGBA()
+			sr += uint64(cr + 0xffff - ca)
+			sg += uint64(cg + 0xffff - ca)
+			sb += uint64(cb + 0xffff - ca)
+			n++
+		}
+	}
+	return relativeLuminance(color.RGBA64{uint16(sr / n), uint16(sg / n), uint16(sb / n), 0xffff})
+}
+
+// checkModuleContrast measures every module, and the quiet zone as light
+// modules, and fails if the dark modules come too close to the light ones.
+// With inverted reflectance dark modules are expected to be the lighter ones.
+// Modules under logos and overlays are not measured.
+func (qr *QRCode) checkModuleContrast(img image.Image, moduleSize, quietZone int, inverted bool) error {
+	var logos []LogoPlacement
+	for _, o := range qr.overlays() {
+		logos = append(logos, o.placement(qr.Version))
+	}
+	underLogo := func(x, y int) bool {
+		for _, p := range logos {
+			if isLogoArea(x, y, p) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Luminance is flipped for inverted symbols, so "lighter" always means
+	// closer to the light module reflectance
+	reflect := func(lum float64) float64 {
+		if inverted {
+			return -lum
+		}
+		return lum
+	}
+
+	// Darkest light module and lightest dark module
+	var darkest, lightest [2]int
+	light, dark := math.Inf(1), math.Inf(-1)
+
+	q := quietModules(moduleSize, quietZone)
+	for y := -q; y < qr.Size+q; y++ {
+		for x := -q; x < qr.Size+q; x++ {
+			if underLogo(x, y) {
+				continue
+			}
+			lum := reflect(sampleArea(img, moduleArea(x, y, qr.Size, moduleSize, quietZone)))
+			if qr.isDarkModule(x, y) {
+				if lum > dark {
+					dark, lightest = lum, [2]int{x, y}
+				}
+			} else if lum < light {
+				light, darkest = lum, [2]int{x, y}
+			}
+		}
+	}
+	if math.IsInf(dark, -1) || math.IsInf(light, 1) {
+		return nil
+	}
+
+	ratio := (light + 0.05) / (dark + 0.05)
+	if inverted {
+		ratio = (-dark + 0.05) / (-light + 0.05)
+	}
+	if ratio < MinModuleContrast {
+		return fmt.Errorf("light module (%d, %d) and dark module (%d, %d) have contrast ratio %.2f, need at least %.1f; use a background plate or a lighter image",
+			darkest[0], darkest[1], lightest[0], lightest[1], ratio, MinModuleContrast)
+	}
+	return nil
+}
+
+// verifyQuietZone checks that every quiet zone module reads like a light
+// module, i.e. is closer to BackgroundColor than to ForegroundColor. This
+// catches inverted symbols whose quiet zone was left light, for example by a
+// transparent background.
+func (qr *QRCode) verifyQuietZone(img image.Image, config StyleConfig, moduleSize, quietZone int) error {
+	light := relativeLuminance(opaque(config.BackgroundColor))
+	dark := relativeLuminance(opaque(config.ForegroundColor))
+
+	q := quietModules(moduleSize, quietZone)
+	for y := -q; y < qr.Size+q; y++ {
+		for x := -q; x < qr.Size+q; x++ {
+			if x >= 0 && x < qr.Size && y >= 0 && y < qr.Size {
+				continue
+			}
+			lum := sampleArea(img, moduleArea(x, y, qr.Size, moduleSize, quietZone))
+			if math.Abs(lum-light) > math.Abs(lum-dark) {
+				return fmt.Errorf("quiet zone at module (%d, %d) reads as a dark module; an inverted symbol needs an opaque dark background", x, y)
+			}
+		}
+	}
+	return nil
+}
+
+// opaque composites a color over white, as sampleLuminance sees it
+func opaque(c color.Color) color.Color {
+	r, g, b, a := c.RGBA()
+	return color.RGBA64{uint16(r + 0xffff - a), uint16(g + 0xffff - a), uint16(b + 0xffff - a), 0xffff}
+}
@@ -0,0 +1,160 @@
+package myqrcode
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+	"testing"
+)
+
+// createTestPhoto makes a busy picture with dark and mid-tone regions
+func createTestPhoto(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r := uint8(40 + 180*x/width)
+			g := uint8(30 + 120*y/height)
+			b := uint8(60 + 100*((x/23+y/17)%2))
+			img.Set(x, y, color.RGBA{r, g, b, 255})
+		}
+	}
+	return img
+}
+
+func TestBackgroundImagePlates(t *testing.T) {
+	qr, err := New("https://meet.google.com/abc-defg-hij", High)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	photo := createTestPhoto(640, 480)
+
+	testCases := []struct {
+		name    string
+		plate   PlateMode
+		wantErr bool
+	}{
+		{"no_plate", PlateNone, true},
+		{"module_plate", PlateModules, false},
+		{"quiet_zone_plate", PlateQuietZone, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := DefaultStyleConfig()
+			config.ModuleSize = 10
+			config.QuietZone = 40
+			config.ModuleDrawer = NewCircleModuleDrawer()
+			WithBackgroundImage(photo, BackgroundFill)(&config)
+			WithBackgroundPlate(tc.plate, nil)(&config)
+
+			img, err := qr.ToImage(config)
+			if tc.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "contrast") {
+					t.Fatalf("Expected contrast error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to generate image: %v", err)
+			}
+
+			// Light module centers are light, dark module centers dark
+			lum := qr.ModuleLuminance(img, config.ModuleSize, config.QuietZone)
+			for y := 0; y < qr.Size; y++ {
+				for x := 0; x < qr.Size; x++ {
+					if qr.Matrix[y][x] != (lum[y][x] < 0.2) {
+						t.Fatalf("Module (%d, %d) luminance %.2f", x, y, lum[y][x])
+					}
+				}
+			}
+
+			saveTestImage(t, img, "test_background_"+tc.name+".png")
+		})
+	}
+}
+
+func TestBackgroundScaling(t *testing.T) {
+	qr, err := New("HELLO WORLD", Medium)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	// A light, wide picture that passes the contrast check on its own
+	wide := image.NewRGBA(image.Rect(0, 0, 100, 50))
+	tint := color.RGBA{255, 235, 235, 255}
+	draw.Draw(wide, wide.Bounds(), &image.Uniform{tint}, image.Point{}, draw.Src)
+
+	white := color.RGBA{255, 255, 255, 255}
+	testCases := []struct {
+		name    string
+		scaling BackgroundScaling
+		corner  color.RGBA // Top-left pixel
+		middle  color.RGBA // Left edge, half way down
+	}{
+		{"fill", BackgroundFill, tint, tint},
+		{"fit", BackgroundFit, white, tint},
+		{"center", BackgroundCenter, white, white},
+	}
+
+	for _, tc := range testCases {
+		config := StyleConfig{ModuleSize: 10, QuietZone: 40}
+		WithBackgroundImage(wide, tc.scaling)(&config)
+
+		img, err := qr.ToImage(config)
+		if err != nil {
+			t.Fatalf("%s: failed to generate image: %v", tc.name, err)
+		}
+
+		size := img.Bounds().Dx()
+		if got := color.RGBAModel.Convert(img.At(0, 0)); got != tc.corner {
+			t.Errorf("%s: corner %v, want %v", tc.name, got, tc.corner)
+		}
+		if got := color.RGBAModel.Convert(img.At(0, size/2)); got != tc.middle {
+			t.Errorf("%s: left edge %v, want %v", tc.name, got, tc.middle)
+		}
+		if got := color.RGBAModel.Convert(img.At(size/2-40, 20)); tc.scaling == BackgroundCenter && got != white {
+			t.Errorf("%s: expected unscaled picture, got %v above the symbol", tc.name, got)
+		}
+	}
+}
+
+func TestPartialQuietZone(t *testing.T) {
+	qr, err := New("https://meet.google.com/abc-defg-hij", High)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	// DefaultStyleConfig has a quiet zone of half a module
+	config := DefaultStyleConfig()
+	WithBackgroundImage(createTestPhoto(640, 480), BackgroundFill)(&config)
+
+	// Without a plate the photo shows through the quiet zone
+	if _, err := qr.ToImage(config); err == nil || !strings.Contains(err.Error(), "contrast") {
+		t.Errorf("Expected contrast error in the quiet zone, got %v", err)
+	}
+
+	WithBackgroundPlate(PlateModules, nil)(&config)
+	img, err := qr.ToImage(config)
+	if err != nil {
+		t.Fatalf("Failed to generate image: %v", err)
+	}
+	if lum := sampleArea(img, image.Rect(0, 0, config.QuietZone, config.QuietZone)); lum < 0.5 {
+		t.Errorf("Expected the plate in the quiet zone, got luminance %.2f", lum)
+	}
+
+	// An inverted symbol needs a dark quiet zone, however narrow
+	if err := qr.verifyQuietZone(img, StyleConfig{ForegroundColor: color.White, BackgroundColor: color.Black}, config.ModuleSize, config.QuietZone); err == nil {
+		t.Error("Expected quiet zone error for a light partial quiet zone")
+	}
+}
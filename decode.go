@@ -0,0 +1,272 @@
+package myqrcode
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+// DecodeResult holds the payload and symbol parameters read back from a matrix
+type DecodeResult struct {
+	Data            []byte // Raw payload bytes, concatenated across all segments
+	Version         int
+	ErrorCorrection ErrorCorrectionLevel
+	Mask            int
+	Corrected       int // Number of codewords fixed by error correction
+}
+
+// DecodeMatrix reads a module matrix (as produced by Encode, indexed [row][col])
+// back into its payload. Byte segments are returned verbatim, so binary data
+// created with NewBytes round-trips exactly.
+func DecodeMatrix(modules [][]bool) (*DecodeResult, error) {
+	size := len(modules)
+	if size < 21 || (size-17)%4 != 0 {
+		return nil, fmt.Errorf("invalid matrix size %d", size)
+	}
+	for _, row := range modules {
+		if len(row) != size {
+			return nil, errors.New("matrix is not square")
+		}
+	}
+
+	version := (size - 17) / 4
+	if version > len(versionTable) {
+		return nil, fmt.Errorf("unsupported version %d", version)
+	}
+
+	level, mask, err := readFormatInfo(modules)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read the masked data region in placement order
+	layout := newSymbolMatrix(version)
+	positions := dataModulePositions(layout)
+
+	info := getVersionInfo(version)
+	blockInfo := info.ECBlockInfo[level]
+
+	totalCodewords := 0
+	for _, group := range blockInfo {
+		totalCodewords += group.NumBlocks * group.TotalCodewords
+	}
+
+	raw := make([]int, totalCodewords*8)
+	for i := range raw {
+		pos := positions[i]
+		bit := modules[pos[1]][pos[0]]
+		if shouldMask(pos[0], pos[1], mask) {
+			bit = !bit
+		}
+		if bit {
+			raw[i] = 1
+		}
+	}
+	codewords := bitsToBytes(raw)
+
+	blocks := deinterleaveBlocks(codewords, blockInfo)
+
+	// Correct each block and collect its data codewords
+	var data []byte
+	corrected := 0
+	for i, block := range blocks {
+		dataLen := block.dataCodewords
+		n, err := correctErrors(block.codewords, len(block.codewords)-dataLen)
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %w", i, err)
+		}
+		corrected += n
+		data = append(data, block.codewords[:dataLen]...)
+	}
+
+	payload, err := parseSegments(bytesToBits(data), version)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DecodeResult{
+		Data:            payload,
+		Version:         version,
+		ErrorCorrection: level,
+		Mask:            mask,
+		Corrected:       corrected,
+	}, nil
+}
+
+// readFormatInfo reads both copies of the format information and returns the
+// level and mask of the closest valid format code
+func readFormatInfo(modules [][]bool) (ErrorCorrectionLevel, int, error) {
+	size := len(modules)
+	get := func(x, y int) int {
+		if modules[y][x] {
+			return 1
+		}
+		return 0
+	}
+
+	// Same positions AddFormatInfo writes to
+	first, second := 0, 0
+	for i := 0; i < 15; i++ {
+		switch {
+		case i < 6:
+			first |= get(8, i) << i
+		case i < 8:
+			first |= get(8, i+1) << i
+		case i == 8:
+			first |= get(7, 8) << i
+		default:
+			first |= get(14-i, 8) << i
+		}
+
+		if i < 8 {
+			second |= get(size-1-i, 8) << i
+		} else {
+			second |= get(8, size-15+i) << i
+		}
+	}
+
+	bestDistance := 16
+	var bestLevel ErrorCorrectionLevel
+	bestMask := 0
+	for level := Low; level <= High; level++ {
+		for mask := 0; mask < 8; mask++ {
+			code := getFormatBits(level, mask)
+			distance := min(bits.OnesCount(uint(first^code)), bits.OnesCount(uint(second^code)))
+			if distance < bestDistance {
+				bestDistance = distance
+				bestLevel = level
+				bestMask = mask
+			}
+		}
+	}
+
+	// The format BCH code has a minimum distance of 7
+	if bestDistance > 3 {
+		return 0, 0, errors.New("unreadable format information")
+	}
+	return bestLevel, bestMask, nil
+}
+
+type codewordBlock struct {
+	codewords     []byte
+	dataCodewords int
+}
+
+// deinterleaveBlocks reverses the codeword interleaving done by addErrorCorrection
+func deinterleaveBlocks(codewords []byte, blockInfo []BlockInfo) []codewordBlock {
+	var blocks []codewordBlock
+	maxData, maxEc := 0, 0
+	for _, group := range blockInfo {
+		for i := 0; i < group.NumBlocks; i++ {
+			blocks = append(blocks, codewordBlock{
+				codewords:     make([]byte, 0, group.TotalCodewords),
+				dataCodewords: group.DataCodewords,
+			})
+		}
+		maxData = max(maxData, group.DataCodewords)
+		maxEc = max(maxEc, group.TotalCodewords-group.DataCodewords)
+	}
+
+	offset := 0
+	for i := 0; i < maxData; i++ {
+		for b := range blocks {
+			if i < blocks[b].dataCodewords {
+				blocks[b].codewords = append(blocks[b].codewords, codewords[offset])
+				offset++
+			}
+		}
+	}
+
+	// Every block in a version has the same number of EC codewords
+	for i := 0; i < maxEc; i++ {
+		for b := range blocks {
+			blocks[b].codewords = append(blocks[b].codewords, codewords[offset])
+			offset++
+		}
+	}
+
+	return blocks
+}
+
+// parseSegments decodes the segment bit stream into raw bytes. Numeric and
+// alphanumeric segments are returned as their ASCII characters.
+func parseSegments(stream []int, version int) ([]byte, error) {
+	pos := 0
+	read := func(n int) (int, bool) {
+		if pos+n > len(stream) {
+			return 0, false
+		}
+		val := 0
+		for i := 0; i < n; i++ {
+			val = val<<1 | stream[pos+i]
+		}
+		pos += n
+		return val, true
+	}
+
+	var result []byte
+	for {
+		indicator, ok := read(4)
+		if !ok || indicator == 0 {
+			break // Terminator or end of data
+		}
+
+		var mode EncodingMode
+		switch indicator {
+		case 1:
+			mode = Numeric
+		case 2:
+			mode = Alphanumeric
+		case 4:
+			mode = Byte
+		default:
+			return nil, fmt.Errorf("unsupported segment mode indicator %04b", indicator)
+		}
+
+		count, ok := read(getCharCountBits(mode, version))
+		if !ok {
+			return nil, errors.New("truncated segment header")
+		}
+
+		switch mode {
+		case Numeric:
+			for count > 0 {
+				digits := min(count, 3)
+				val, ok := read([]int{0, 4, 7, 10}[digits])
+				if !ok || val >= []int{1, 10, 100, 1000}[digits] {
+					return nil, errors.New("invalid numeric segment")
+				}
+				result = append(result, fmt.Sprintf("%0*d", digits, val)...)
+				count -= digits
+			}
+		case Alphanumeric:
+			for count > 0 {
+				if count >= 2 {
+					val, ok := read(11)
+					if !ok || val >= 45*45 {
+						return nil, errors.New("invalid alphanumeric segment")
+					}
+					result = append(result, alphanumericChars[val/45], alphanumericChars[val%45])
+					count -= 2
+				} else {
+					val, ok := read(6)
+					if !ok || val >= 45 {
+						return nil, errors.New("invalid alphanumeric segment")
+					}
+					result = append(result, alphanumericChars[val])
+					count--
+				}
+			}
+		case Byte:
+			for ; count > 0; count-- {
+				val, ok := read(8)
+				if !ok {
+					return nil, errors.New("truncated byte segment")
+				}
+				result = append(result, byte(val))
+			}
+		}
+	}
+
+	return result, nil
+}
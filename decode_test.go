@@ -0,0 +1,191 @@
+package myqrcode
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDecodeRoundTrip(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+	}{
+		{"Numeric", "01234567890123456789"},
+		{"Alphanumeric", "HELLO WORLD $%*+-./:"},
+		{"Byte", "https://meet.google.com/abc-defg-hij"},
+		{"UTF-8", "¡Hola, señor! 你好"},
+		{"Version 7+", strings.Repeat("https://example.com/", 8)},
+		{"Large", strings.Repeat("The quick brown fox jumps over the lazy dog. ", 20)},
+	}
+
+	levels := []ErrorCorrectionLevel{Low, Medium, Quartile, High}
+
+	for _, tc := range testCases {
+		for _, level := range levels {
+			t.Run(fmt.Sprintf("%s_%d", tc.name, level), func(t *testing.T) {
+				qr, err := New(tc.data, level)
+				if err != nil {
+					t.Fatalf("Failed to create QR code: %v", err)
+				}
+				if err := qr.Encode(); err != nil {
+					t.Fatalf("Failed to encode QR code: %v", err)
+				}
+
+				result, err := DecodeMatrix(qr.Matrix)
+				if err != nil {
+					t.Fatalf("Failed to decode version %d matrix: %v", qr.Version, err)
+				}
+
+				if string(result.Data) != tc.data {
+					t.Errorf("Decoded %q, want %q", result.Data, tc.data)
+				}
+				if result.Version != qr.Version || result.ErrorCorrection != qr.ErrorCorrection {
+					t.Errorf("Decoded version %d level %d, want version %d level %d",
+						result.Version, result.ErrorCorrection, qr.Version, qr.ErrorCorrection)
+				}
+				if result.Corrected != 0 {
+					t.Errorf("Expected a clean decode, corrected %d codewords", result.Corrected)
+				}
+			})
+		}
+	}
+}
+
+func TestNewBytesRoundTrip(t *testing.T) {
+	payloads := map[string][]byte{
+		"Binary":        {0x00, 0xff, 0x10, 0x80, 0x7f, 0x00, 0x01, 0xfe},
+		"Looks numeric": []byte("31415926535"),
+		"All bytes": func() []byte {
+			b := make([]byte, 256)
+			for i := range b {
+				b[i] = byte(i)
+			}
+			return b
+		}(),
+	}
+
+	for name, data := range payloads {
+		t.Run(name, func(t *testing.T) {
+			qr, err := NewBytes(data, Medium)
+			if err != nil {
+				t.Fatalf("Failed to create QR code: %v", err)
+			}
+			if err := qr.Encode(); err != nil {
+				t.Fatalf("Failed to encode QR code: %v", err)
+			}
+
+			if qr.Mode != Byte {
+				t.Errorf("Expected Byte mode, got %s", qr.Mode)
+			}
+
+			result, err := DecodeMatrix(qr.Matrix)
+			if err != nil {
+				t.Fatalf("Failed to decode: %v", err)
+			}
+			if !bytes.Equal(result.Data, data) {
+				t.Errorf("Decoded %x, want %x", result.Data, data)
+			}
+		})
+	}
+
+	if _, err := NewBytes(nil, Medium); err == nil {
+		t.Error("Expected error for empty data")
+	}
+}
+
+func TestDecodeCorrectsErrors(t *testing.T) {
+	data := "https://meet.google.com/abc-defg-hij"
+
+	qr, err := New(data, High)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	// Flip a handful of data modules in the bottom-right corner
+	damaged := make([][]bool, qr.Size)
+	for i := range qr.Matrix {
+		damaged[i] = append([]bool(nil), qr.Matrix[i]...)
+	}
+	for y := qr.Size - 3; y < qr.Size; y++ {
+		for x := qr.Size - 4; x < qr.Size; x++ {
+			damaged[y][x] = !damaged[y][x]
+		}
+	}
+
+	result, err := DecodeMatrix(damaged)
+	if err != nil {
+		t.Fatalf("Failed to decode damaged matrix: %v", err)
+	}
+	if string(result.Data) != data {
+		t.Errorf("Decoded %q, want %q", result.Data, data)
+	}
+	if result.Corrected == 0 {
+		t.Error("Expected corrected codewords to be reported")
+	}
+}
+
+func TestDecodeWithLogo(t *testing.T) {
+	data := "https://meet.google.com/logo-test"
+
+	qr, err := New(data, High)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	qr.SetLogo(createSimpleLogo(), 15)
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	result, err := DecodeMatrix(qr.Matrix)
+	if err != nil {
+		t.Fatalf("Failed to decode matrix with logo area: %v", err)
+	}
+	if string(result.Data) != data {
+		t.Errorf("Decoded %q, want %q", result.Data, data)
+	}
+	t.Logf("Logo area cost %d corrected codewords", result.Corrected)
+}
+
+func TestDecodeInvalidMatrix(t *testing.T) {
+	if _, err := DecodeMatrix(make([][]bool, 20)); err == nil {
+		t.Error("Expected error for invalid matrix size")
+	}
+
+	blank := make([][]bool, 21)
+	for i := range blank {
+		blank[i] = make([]bool, 21)
+	}
+	if _, err := DecodeMatrix(blank); err == nil {
+		t.Error("Expected error for blank matrix")
+	}
+}
+
+func TestParseSegmentsRejectsInvalidNumeric(t *testing.T) {
+	bits := func(val, n int) []int {
+		out := make([]int, n)
+		for i := range out {
+			out[i] = val >> (n - 1 - i) & 1
+		}
+		return out
+	}
+
+	// Numeric mode, three digits, then a 10-bit group
+	for _, tt := range []struct {
+		group int
+		valid bool
+	}{{999, true}, {1000, false}, {1023, false}} {
+		stream := append(append(bits(1, 4), bits(3, 10)...), bits(tt.group, 10)...)
+		data, err := parseSegments(stream, 1)
+		if tt.valid && (err != nil || string(data) != "999") {
+			t.Errorf("Expected group %d to decode as 999, got %q, %v", tt.group, data, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("Expected an error for numeric group %d, got %q", tt.group, data)
+		}
+	}
+}
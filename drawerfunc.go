@@ -0,0 +1,217 @@
+package myqrcode
+
+import (
+	"encoding/binary"
+	"image"
+	"image/draw"
+	"math"
+
+	"golang.org/x/image/vector"
+)
+
+// DrawerFunc draws one dark module as a path on the canvas. It is the quick
+// way to prototype a module shape; NewFuncModuleDrawer turns it into a
+// ModuleDrawer that handles anti-aliasing, colors and caching.
+type DrawerFunc func(c *Canvas, m ModuleContext)
+
+// ModuleContext describes the module a DrawerFunc is asked to draw
+type ModuleContext struct {
+	X, Y      int // Module coordinates, (0, 0) is the top-left module
+	Size      int // Modules per side of the symbol
+	Role      ModuleRole
+	Neighbors ActiveWithNeighbors
+}
+
+// Canvas collects a path in module units: (0, 0) is the module's top-left
+// corner and (1, 1) its bottom-right. Shapes may reach up to one module
+// beyond the module on every side. Overlapping shapes are merged.
+type Canvas struct {
+	ops []float32 // Recorded path, also used as the cache key
+}
+
+const (
+	canvasMove float32 = iota
+	canvasLine
+	canvasQuad
+	canvasCube
+	canvasClose
+)
+
+func (c *Canvas) MoveTo(x, y float64) {
+	c.ops = append(c.ops, canvasMove, float32(x), float32(y))
+}
+
+func (c *Canvas) LineTo(x, y float64) {
+	c.ops = append(c.ops, canvasLine, float32(x), float32(y))
+}
+
+func (c *Canvas) QuadTo(cx, cy, x, y float64) {
+	c.ops = append(c.ops, canvasQuad, float32(cx), float32(cy), float32(x), float32(y))
+}
+
+func (c *Canvas) CubeTo(c1x, c1y, c2x, c2y, x, y float64) {
+	c.ops = append(c.ops, canvasCube, float32(c1x), float32(c1y), float32(c2x), float32(c2y), float32(x), float32(y))
+}
+
+// Close closes the current subpath
+func (c *Canvas) Close() {
+	c.ops = append(c.ops, canvasClose)
+}
+
+// Rect adds a rectangle
+func (c *Canvas) Rect(x, y, w, h float64) {
+	c.RoundedRect(x, y, w, h, 0)
+}
+
+// RoundedRect adds a rectangle with all corners rounded by radius r
+func (c *Canvas) RoundedRect(x, y, w, h, r float64) {
+	radius := float32(r)
+	c.contour(roundedRect(float32(x), float32(y), float32(w), float32(h), [4]float32{radius, radius, radius, radius}))
+}
+
+// Circle adds a circle
+func (c *Canvas) Circle(cx, cy, r float64) {
+	c.contour(circle(float32(cx), float32(cy), float32(r)))
+}
+
+func (c *Canvas) contour(ct *contour) {
+	c.ops = append(c.ops, canvasMove, ct.start.X, ct.start.Y)
+	for _, s := range ct.segments {
+		if s.curve {
+			c.ops = append(c.ops, canvasCube, s.c1.X, s.c1.Y, s.c2.X, s.c2.Y, s.p.X, s.p.Y)
+		} else {
+			c.ops = append(c.ops, canvasLine, s.p.X, s.p.Y)
+		}
+	}
+	c.ops = append(c.ops, canvasClose)
+}
+
+// rasterize renders the path into a coverage mask of 3x3 modules of
+// moduleSize pixels, with the module itself in the middle
+func (c *Canvas) rasterize(moduleSize int) *image.Alpha {
+	size := 3 * moduleSize
+	m := float32(moduleSize)
+	at := func(v float32) float32 { return (v + 1) * m }
+
+	z := vector.NewRasterizer(size, size)
+	open := false
+	var pen, start point
+	for i := 0; i < len(c.ops); {
+		switch c.ops[i] {
+		case canvasMove:
+			if open {
+				z.ClosePath()
+			}
+			pen = point{at(c.ops[i+1]), at(c.ops[i+2])}
+			start = pen
+			z.MoveTo(pen.X, pen.Y)
+			open = true
+			i += 3
+		case canvasLine:
+			pen = point{at(c.ops[i+1]), at(c.ops[i+2])}
+			z.LineTo(pen.X, pen.Y)
+			i += 3
+		case canvasQuad:
+			// Raise the quadratic to a cubic to share its flattening
+			q, p := point{at(c.ops[i+1]), at(c.ops[i+2])}, point{at(c.ops[i+3]), at(c.ops[i+4])}
+			cubeTo(z, pen, point{pen.X + 2.0/3*(q.X-pen.X), pen.Y + 2.0/3*(q.Y-pen.Y)}, point{p.X + 2.0/3*(q.X-p.X), p.Y + 2.0/3*(q.Y-p.Y)}, p)
+			pen = p
+			i += 5
+		case canvasCube:
+			p := point{at(c.ops[i+5]), at(c.ops[i+6])}
+			cubeTo(z, pen, point{at(c.ops[i+1]), at(c.ops[i+2])}, point{at(c.ops[i+3]), at(c.ops[i+4])}, p)
+			pen = p
+			i += 7
+		case canvasClose:
+			if open {
+				z.ClosePath()
+			}
+			pen = start
+			open = false
+			i++
+		}
+	}
+	if open {
+		z.ClosePath()
+	}
+
+	mask := image.NewAlpha(image.Rect(0, 0, size, size))
+	z.Draw(mask, mask.Bounds(), image.Opaque, image.Point{})
+	return mask
+}
+
+// key returns the recorded path as a map key
+func (c *Canvas) key() string {
+	buf := make([]byte, 4*len(c.ops))
+	for i, v := range c.ops {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+	}
+	return string(buf)
+}
+
+// FuncModuleDrawer adapts a DrawerFunc to the ModuleDrawer interface.
+// It draws every dark module, including finder modules when no FinderDrawer
+// is set. Identical paths are rasterized once.
+type FuncModuleDrawer struct {
+	BaseModuleDrawer
+	Draw DrawerFunc
+
+	roles  [][]ModuleRole
+	cache  map[string]*image.Alpha
+	canvas Canvas
+}
+
+func NewFuncModuleDrawer(fn DrawerFunc) *FuncModuleDrawer {
+	return &FuncModuleDrawer{Draw: fn}
+}
+
+func (f *FuncModuleDrawer) NeedsNeighbors() bool {
+	return true
+}
+
+func (f *FuncModuleDrawer) Initialize(img *image.RGBA, config StyleConfig) {
+	f.BaseModuleDrawer.Initialize(img, config)
+	f.cache = make(map[string]*image.Alpha)
+}
+
+func (f *FuncModuleDrawer) Prepare(qr *QRCode, symbol image.Rectangle) {
+	f.roles = qr.ModuleRoles()
+}
+
+// DrawModule draws nothing: the callback needs the module's position, which
+// only DrawModuleAt receives
+func (f *FuncModuleDrawer) DrawModule(box [4]int, isActive bool, neighbors *ActiveWithNeighbors) {}
+
+func (f *FuncModuleDrawer) DrawModuleAt(box [4]int, x, y int, isActive bool, neighbors *ActiveWithNeighbors) {
+	if !isActive || f.Draw == nil || f.roles == nil {
+		return
+	}
+
+	width := box[2] - box[0]
+	ctx := ModuleContext{X: x, Y: y, Size: len(f.roles)}
+	if x < 0 || y < 0 || x >= ctx.Size || y >= ctx.Size {
+		return
+	}
+	ctx.Role = f.roles[y][x]
+	if neighbors != nil {
+		ctx.Neighbors = *neighbors
+	}
+
+	f.canvas.ops = f.canvas.ops[:0]
+	f.Draw(&f.canvas, ctx)
+	if len(f.canvas.ops) == 0 {
+		return
+	}
+
+	key := f.canvas.key()
+	mask, ok := f.cache[key]
+	if !ok {
+		mask = f.config.Quality.coverage(func(scale int) *image.Alpha {
+			return f.canvas.rasterize(width * scale)
+		})
+		f.cache[key] = mask
+	}
+
+	dst := image.Rect(box[0]-width, box[1]-width, box[2]+width, box[3]+width)
+	draw.DrawMask(f.img, dst, &image.Uniform{f.config.ForegroundColor}, image.Point{}, mask, image.Point{}, draw.Over)
+}
@@ -0,0 +1,150 @@
+package myqrcode
+
+import (
+	"testing"
+)
+
+// heart draws a heart filling most of the module
+func heart(c *Canvas, m ModuleContext) {
+	if m.Role == RoleFinderRing || m.Role == RoleFinderCenter {
+		c.Rect(0, 0, 1, 1) // Keep finder patterns solid
+		return
+	}
+	c.MoveTo(0.5, 0.95)
+	c.CubeTo(0.1, 0.65, -0.05, 0.35, 0.2, 0.12)
+	c.CubeTo(0.35, 0, 0.5, 0.1, 0.5, 0.25)
+	c.CubeTo(0.5, 0.1, 0.65, 0, 0.8, 0.12)
+	c.CubeTo(1.05, 0.35, 0.9, 0.65, 0.5, 0.95)
+	c.Close()
+}
+
+func TestDrawerFunc(t *testing.T) {
+	qr, err := New("https://meet.google.com/abc-defg-hij", High)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	roles := qr.ModuleRoles()
+	seen := make(map[[2]int]bool)
+	drawer := NewFuncModuleDrawer(func(c *Canvas, m ModuleContext) {
+		if !qr.Matrix[m.Y][m.X] {
+			t.Errorf("Callback for light module (%d, %d)", m.X, m.Y)
+		}
+		if m.Role != roles[m.Y][m.X] || m.Size != qr.Size {
+			t.Errorf("Module (%d, %d): role %s size %d", m.X, m.Y, m.Role, m.Size)
+		}
+		if m.Neighbors.E != (m.X+1 < qr.Size && qr.Matrix[m.Y][m.X+1]) {
+			t.Errorf("Module (%d, %d): wrong east neighbor", m.X, m.Y)
+		}
+		seen[[2]int{m.X, m.Y}] = true
+		heart(c, m)
+	})
+
+	config := DefaultStyleConfig()
+	config.ModuleSize = 12
+	config.QuietZone = 48
+	config.ModuleDrawer = drawer
+
+	img, err := qr.ToImage(config)
+	if err != nil {
+		t.Fatalf("Failed to generate image: %v", err)
+	}
+
+	dark := 0
+	for y := 0; y < qr.Size; y++ {
+		for x := 0; x < qr.Size; x++ {
+			if qr.Matrix[y][x] {
+				dark++
+			}
+			px := config.QuietZone + x*config.ModuleSize + config.ModuleSize/2
+			py := config.QuietZone + y*config.ModuleSize + config.ModuleSize/2
+			if isDark(img, px, py) != qr.Matrix[y][x] {
+				t.Fatalf("Module (%d, %d) center does not match its bit", x, y)
+			}
+		}
+	}
+	if len(seen) != dark {
+		t.Errorf("Callback saw %d modules, want %d dark modules", len(seen), dark)
+	}
+
+	// Two distinct paths: heart and finder square
+	if len(drawer.cache) != 2 {
+		t.Errorf("Expected 2 cached masks, got %d", len(drawer.cache))
+	}
+
+	saveTestImage(t, img, "test_drawer_func_hearts.png")
+}
+
+func TestDrawerFuncBleed(t *testing.T) {
+	qr, err := New("HELLO WORLD", Medium)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	// Bridge into the east neighbor when it is dark
+	img, err := qr.ToImage(StyleConfig{
+		ModuleSize: 10,
+		QuietZone:  40,
+		ModuleDrawer: NewFuncModuleDrawer(func(c *Canvas, m ModuleContext) {
+			c.Circle(0.5, 0.5, 0.35)
+			if m.Neighbors.E {
+				c.Rect(0.5, 0.4, 1, 0.2)
+			}
+		}),
+	})
+	if err != nil {
+		t.Fatalf("Failed to generate image: %v", err)
+	}
+
+	for y := 0; y < qr.Size; y++ {
+		for x := 0; x+1 < qr.Size; x++ {
+			if qr.Matrix[y][x] && qr.Matrix[y][x+1] {
+				// The gap between two circles is bridged
+				if !isDark(img, 40+x*10+10, 40+y*10+5) {
+					t.Fatalf("Expected bridge between (%d, %d) and its east neighbor", x, y)
+				}
+				return
+			}
+		}
+	}
+}
+
+// positionRecorder records the grid positions ToImage passes to DrawModuleAt
+type positionRecorder struct {
+	BaseModuleDrawer
+	seen map[[2]int]bool
+}
+
+func (p *positionRecorder) DrawModule(box [4]int, isActive bool, neighbors *ActiveWithNeighbors) {}
+
+func (p *positionRecorder) DrawModuleAt(box [4]int, x, y int, isActive bool, neighbors *ActiveWithNeighbors) {
+	p.seen[[2]int{x, y}] = true
+}
+
+func TestPositionedModuleDrawer(t *testing.T) {
+	qr, err := New("HELLO WORLD", Medium)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	// Every module, finder modules included, arrives with its position
+	recorder := &positionRecorder{seen: make(map[[2]int]bool)}
+	if _, err := qr.ToImage(StyleConfig{ModuleSize: 7, QuietZone: 3, ModuleDrawer: recorder}); err != nil {
+		t.Fatalf("Failed to generate image: %v", err)
+	}
+	if len(recorder.seen) != qr.Size*qr.Size {
+		t.Errorf("Expected %d positions, got %d", qr.Size*qr.Size, len(recorder.seen))
+	}
+	if !recorder.seen[[2]int{0, 0}] || !recorder.seen[[2]int{qr.Size - 1, qr.Size - 1}] {
+		t.Error("Expected the corner modules to be passed to DrawModuleAt")
+	}
+}
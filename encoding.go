@@ -2,6 +2,7 @@ package myqrcode
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 )
@@ -18,6 +19,25 @@ func detectMode(data string) EncodingMode {
 	return Byte
 }
 
+// validateMode checks that a forced encoding mode can represent the data
+func validateMode(data string, mode EncodingMode) error {
+	switch mode {
+	case Numeric:
+		if !isNumeric(data) {
+			return fmt.Errorf("data cannot be encoded in %s mode: only digits 0-9 are allowed", mode)
+		}
+	case Alphanumeric:
+		if !isAlphanumeric(data) {
+			return fmt.Errorf("data cannot be encoded in %s mode: only %q are allowed", mode, alphanumericChars)
+		}
+	case Byte:
+		// Any data can be encoded as bytes
+	default:
+		return fmt.Errorf("unsupported encoding mode %s", mode)
+	}
+	return nil
+}
+
 func isNumeric(data string) bool {
 	for _, c := range data {
 		if c < '0' || c > '9' {
@@ -64,7 +84,10 @@ func encodeNumeric(data string, version int) ([]int, error) {
 	// Data encoding
 	for i := 0; i < len(data); i += 3 {
 		group := data[i:min(i+3, len(data))]
-		val, _ := strconv.Atoi(group)
+		val, err := strconv.Atoi(group)
+		if err != nil || val < 0 {
+			return nil, fmt.Errorf("invalid numeric data %q", group)
+		}
 
 		bitCount := 10
 		if len(group) == 2 {
@@ -96,16 +119,23 @@ func encodeAlphanumeric(data string, version int) ([]int, error) {
 
 	// Data encoding
 	for i := 0; i < len(data); i += 2 {
+		val1 := strings.IndexByte(alphanumericChars, data[i])
+		if val1 < 0 {
+			return nil, fmt.Errorf("invalid alphanumeric character %q", data[i])
+		}
+
 		if i+1 < len(data) {
-			val1 := strings.IndexRune(alphanumericChars, rune(data[i]))
-			val2 := strings.IndexRune(alphanumericChars, rune(data[i+1]))
+			val2 := strings.IndexByte(alphanumericChars, data[i+1])
+			if val2 < 0 {
+				return nil, fmt.Errorf("invalid alphanumeric character %q", data[i+1])
+			}
 			val := val1*45 + val2
 
 			for j := 10; j >= 0; j-- {
 				bits = append(bits, (val>>j)&1)
 			}
 		} else {
-			val := strings.IndexRune(alphanumericChars, rune(data[i]))
+			val := val1
 			for j := 5; j >= 0; j-- {
 				bits = append(bits, (val>>j)&1)
 			}
@@ -0,0 +1,302 @@
+package myqrcode
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// MinFillContrast is the lowest WCAG contrast ratio between any color of a
+// Fill and the background that ToImage accepts. Below it scanners start to
+// misread modules.
+const MinFillContrast = 3.0
+
+// Fill paints the foreground modules with something other than a flat color.
+// The fill spans the whole symbol, so a gradient runs across all modules
+// instead of restarting in each one.
+type Fill interface {
+	// Image returns the paint for a symbol occupying bounds (in image pixels)
+	Image(bounds image.Rectangle) image.Image
+
+	// Colors returns the colors the fill can produce, for the contrast check
+	Colors() []color.Color
+}
+
+// ColorStop is a color at a position between 0 and 1 along a gradient
+type ColorStop struct {
+	Offset float64
+	Color  color.Color
+}
+
+// LinearGradient blends its stops along a straight line across the symbol.
+// Angle is in degrees: 0 runs left to right, 90 top to bottom.
+type LinearGradient struct {
+	Angle float64
+	Stops []ColorStop
+}
+
+func NewLinearGradient(angle float64, stops ...ColorStop) *LinearGradient {
+	return &LinearGradient{Angle: angle, Stops: stops}
+}
+
+func (g *LinearGradient) Image(bounds image.Rectangle) image.Image {
+	sin, cos := math.Sincos(g.Angle * math.Pi / 180)
+	cx, cy := centerOf(bounds)
+
+	// Offsets 0 and 1 fall on the corners furthest along the gradient line
+	half := math.Abs(float64(bounds.Dx())/2*cos) + math.Abs(float64(bounds.Dy())/2*sin)
+	if half == 0 {
+		half = 1
+	}
+
+	return newGradientImage(bounds, g.Stops, func(x, y float64) float64 {
+		return 0.5 + ((x-cx)*cos+(y-cy)*sin)/(2*half)
+	})
+}
+
+func (g *LinearGradient) Colors() []color.Color {
+	return stopColors(g.Stops)
+}
+
+// RadialGradient blends its stops outward from a center point.
+// CenterX, CenterY and Radius are fractions of the symbol size.
+type RadialGradient struct {
+	CenterX, CenterY float64
+	Radius           float64
+	Stops            []ColorStop
+}
+
+// NewRadialGradient returns a gradient centered on the symbol that reaches
+// its last stop at the corners
+func NewRadialGradient(stops ...ColorStop) *RadialGradient {
+	return &RadialGradient{CenterX: 0.5, CenterY: 0.5, Radius: math.Sqrt2 / 2, Stops: stops}
+}
+
+func (g *RadialGradient) Image(bounds image.Rectangle) image.Image {
+	w, h := float64(bounds.Dx()), float64(bounds.Dy())
+	cx := float64(bounds.Min.X) + g.CenterX*w
+	cy := float64(bounds.Min.Y) + g.CenterY*h
+	radius := g.Radius * max(w, h)
+	if radius <= 0 {
+		radius = 1
+	}
+
+	return newGradientImage(bounds, g.Stops, func(x, y float64) float64 {
+		return math.Hypot(x-cx, y-cy) / radius
+	})
+}
+
+func (g *RadialGradient) Colors() []color.Color {
+	return stopColors(g.Stops)
+}
+
+// ConicGradient sweeps its stops clockwise around the symbol center,
+// starting at Angle degrees (0 points right)
+type ConicGradient struct {
+	Angle float64
+	Stops []ColorStop
+}
+
+func NewConicGradient(angle float64, stops ...ColorStop) *ConicGradient {
+	return &ConicGradient{Angle: angle, Stops: stops}
+}
+
+func (g *ConicGradient) Image(bounds image.Rectangle) image.Image {
+	cx, cy := centerOf(bounds)
+	start := g.Angle * math.Pi / 180
+
+	return newGradientImage(bounds, g.Stops, func(x, y float64) float64 {
+		t := (math.Atan2(y-cy, x-cx) - start) / (2 * math.Pi)
+		return t - math.Floor(t)
+	})
+}
+
+func (g *ConicGradient) Colors() []color.Color {
+	return stopColors(g.Stops)
+}
+
+// TextureFill paints the modules with an image stretched over the symbol
+type TextureFill struct {
+	Texture image.Image
+}
+
+func NewTextureFill(texture image.Image) *TextureFill {
+	return &TextureFill{Texture: texture}
+}
+
+func (f *TextureFill) Image(bounds image.Rectangle) image.Image {
+	dst := image.NewRGBA(bounds)
+	xdraw.CatmullRom.Scale(dst, bounds, f.Texture, f.Texture.Bounds(), xdraw.Src, nil)
+	return dst
+}
+
+// Colors samples the texture at roughly module scale: single light pixels
+// don't matter to a scanner, light regions do
+func (f *TextureFill) Colors() []color.Color {
+	const grid = 16
+	small := image.NewRGBA(image.Rect(0, 0, grid, grid))
+	xdraw.CatmullRom.Scale(small, small.Bounds(), f.Texture, f.Texture.Bounds(), xdraw.Src, nil)
+
+	colors := make([]color.Color, 0, grid*grid)
+	for y := 0; y < grid; y++ {
+		for x := 0; x < grid; x++ {
+			colors = append(colors, small.RGBAAt(x, y))
+		}
+	}
+	return colors
+}
+
+// gradientImage evaluates a gradient lazily, one pixel at a time
+type gradientImage struct {
+	bounds image.Rectangle
+	offset func(x, y float64) float64
+	lut    [256]color.RGBA64
+}
+
+func newGradientImage(bounds image.Rectangle, stops []ColorStop, offset func(x, y float64) float64) *gradientImage {
+	g := &gradientImage{bounds: bounds, offset: offset}
+
+	sorted := append([]ColorStop(nil), stops...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Offset < sorted[j].Offset })
+	for i := range g.lut {
+		g.lut[i] = colorAt(sorted, float64(i)/255)
+	}
+	return g
+}
+
+func (g *gradientImage) ColorModel() color.Model { return color.RGBA64Model }
+
+func (g *gradientImage) Bounds() image.Rectangle { return g.bounds }
+
+func (g *gradientImage) At(x, y int) color.Color {
+	t := g.offset(float64(x)+0.5, float64(y)+0.5)
+	t = max(0, min(1, t))
+	return g.lut[int(t*255+0.5)]
+}
+
+// colorAt interpolates sorted stops at offset t, clamping outside the stops
+func colorAt(stops []ColorStop, t float64) color.RGBA64 {
+	if len(stops) == 0 {
+		return color.RGBA64{0, 0, 0, 0xffff}
+	}
+	if t <= stops[0].Offset {
+		return color.RGBA64Model.Convert(stops[0].Color).(color.RGBA64)
+	}
+
+	for i := 1; i < len(stops); i++ {
+		if t > stops[i].Offset {
+			continue
+		}
+		a := color.RGBA64Model.Convert(stops[i-1].Color).(color.RGBA64)
+		b := color.RGBA64Model.Convert(stops[i].Color).(color.RGBA64)
+		span := stops[i].Offset - stops[i-1].Offset
+		if span <= 0 {
+			return b
+		}
+		f := (t - stops[i-1].Offset) / span
+		lerp := func(a, b uint16) uint16 {
+			return uint16(float64(a) + (float64(b)-float64(a))*f + 0.5)
+		}
+		return color.RGBA64{lerp(a.R, b.R), lerp(a.G, b.G), lerp(a.B, b.B), lerp(a.A, b.A)}
+	}
+
+	return color.RGBA64Model.Convert(stops[len(stops)-1].Color).(color.RGBA64)
+}
+
+func stopColors(stops []ColorStop) []color.Color {
+	colors := make([]color.Color, len(stops))
+	for i, s := range stops {
+		colors[i] = s.Color
+	}
+	return colors
+}
+
+func centerOf(r image.Rectangle) (float64, float64) {
+	return float64(r.Min.X+r.Max.X) / 2, float64(r.Min.Y+r.Max.Y) / 2
+}
+
+// applyFill repaints the foreground of img with the fill. mask holds the same
+// modules drawn black on white, so each pixel's darkness is how much of it is
+// foreground. That share of the flat foreground color is swapped for the fill.
+func applyFill(img *image.RGBA, mask *image.RGBA, paint image.Image, fg color.Color) {
+	fR, fG, fB, fA := fg.RGBA()
+	bounds := paint.Bounds().Intersect(img.Bounds())
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			m := mask.RGBAAt(x, y)
+			coverage := float64(255-m.G) / 255
+			if coverage == 0 {
+				continue
+			}
+
+			pr, pg, pb, pa := paint.At(x, y).RGBA()
+			c := img.RGBAAt(x, y)
+			swap := func(v uint8, from, to uint32) uint8 {
+				out := float64(v) + coverage*(float64(to)-float64(from))/257
+				return uint8(max(0, min(255, out+0.5)))
+			}
+			img.SetRGBA(x, y, color.RGBA{
+				swap(c.R, fR, pr),
+				swap(c.G, fG, pg),
+				swap(c.B, fB, pb),
+				swap(c.A, fA, pa),
+			})
+		}
+	}
+}
+
+// ContrastRatio returns the WCAG contrast ratio between two colors,
+// from 1 (identical luminance) to 21 (black on white)
+func ContrastRatio(a, b color.Color) float64 {
+	la, lb := relativeLuminance(a), relativeLuminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// relativeLuminance returns the WCAG relative luminance of a color
+func relativeLuminance(c color.Color) float64 {
+	n := color.NRGBA64Model.Convert(c).(color.NRGBA64)
+	linear := func(v uint16) float64 {
+		s := float64(v) / 0xffff
+		if s <= 0.03928 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(n.R) + 0.7152*linear(n.G) + 0.0722*linear(n.B)
+}
+
+// checkFillContrast rejects fills with a color too close to the background.
+// A fully transparent background is not checked: what ends up behind the
+// symbol is up to the caller.
+func checkFillContrast(fill Fill, background color.Color) error {
+	if _, _, _, a := background.RGBA(); a == 0 {
+		return nil
+	}
+
+	colors := fill.Colors()
+	if len(colors) == 0 {
+		return errors.New("fill has no colors")
+	}
+
+	worst, worstRatio := colors[0], math.Inf(1)
+	for _, c := range colors {
+		if ratio := ContrastRatio(c, background); ratio < worstRatio {
+			worst, worstRatio = c, ratio
+		}
+	}
+	if worstRatio < MinFillContrast {
+		c := color.NRGBAModel.Convert(worst).(color.NRGBA)
+		return fmt.Errorf("fill color #%02x%02x%02x has contrast ratio %.2f against the background, need at least %.1f",
+			c.R, c.G, c.B, worstRatio, MinFillContrast)
+	}
+	return nil
+}
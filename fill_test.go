@@ -0,0 +1,157 @@
+package myqrcode
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestFills(t *testing.T) {
+	qr, err := New("https://meet.google.com/abc-defg-hij", High)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	purple := color.RGBA{90, 20, 160, 255}
+	teal := color.RGBA{0, 110, 110, 255}
+	stops := []ColorStop{{0, purple}, {1, teal}}
+
+	texture := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			if (x+y)%2 == 0 {
+				texture.Set(x, y, purple)
+			} else {
+				texture.Set(x, y, teal)
+			}
+		}
+	}
+
+	fills := map[string]Fill{
+		"linear":  NewLinearGradient(0, stops...),
+		"radial":  NewRadialGradient(stops...),
+		"conic":   NewConicGradient(-90, stops...),
+		"texture": NewTextureFill(texture),
+	}
+
+	for name, fill := range fills {
+		for _, drawer := range []ModuleDrawer{NewSquareModuleDrawer(), NewCircleModuleDrawer()} {
+			config := DefaultStyleConfig()
+			config.ModuleSize = 10
+			config.QuietZone = 40
+			config.ModuleDrawer = drawer
+			config.FinderDrawer = NewFinderDrawer(EyeRounded, EyeCircle)
+			config.Fill = fill
+
+			img, err := qr.ToImage(config)
+			if err != nil {
+				t.Fatalf("%s: failed to generate image: %v", name, err)
+			}
+
+			// Dark module centers are painted, never left black or light.
+			// Finder corners are rounded away, so only data modules are sampled.
+			roles := qr.ModuleRoles()
+			for y := 0; y < qr.Size; y++ {
+				for x := 0; x < qr.Size; x++ {
+					if roles[y][x] != RoleData {
+						continue
+					}
+					c := color.RGBAModel.Convert(img.At(40+x*10+5, 40+y*10+5)).(color.RGBA)
+					if qr.Matrix[y][x] && (c == color.RGBA{0, 0, 0, 255} || !isDark(img, 40+x*10+5, 40+y*10+5)) {
+						t.Fatalf("%s: module (%d, %d) not filled: %v", name, x, y, c)
+					}
+					if !qr.Matrix[y][x] && c != (color.RGBA{255, 255, 255, 255}) {
+						t.Fatalf("%s: light module (%d, %d) painted: %v", name, x, y, c)
+					}
+				}
+			}
+
+			saveTestImage(t, img, "test_fill_"+name+".png")
+		}
+	}
+}
+
+func TestLinearGradientSpansSymbol(t *testing.T) {
+	qr, err := New("HELLO WORLD", Medium)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	red := color.RGBA{200, 0, 0, 255}
+	blue := color.RGBA{0, 0, 200, 255}
+	img, err := qr.ToImage(StyleConfig{
+		ModuleSize: 10,
+		QuietZone:  40,
+		Fill:       NewLinearGradient(0, ColorStop{0, red}, ColorStop{1, blue}),
+	})
+	if err != nil {
+		t.Fatalf("Failed to generate image: %v", err)
+	}
+
+	// The top-left finder is near the start of the gradient, the top-right
+	// one near its end
+	left := color.RGBAModel.Convert(img.At(41, 41)).(color.RGBA)
+	right := color.RGBAModel.Convert(img.At(40+qr.Size*10-2, 41)).(color.RGBA)
+	if left.R < 190 || left.B > 10 {
+		t.Errorf("Expected red at the left edge, got %v", left)
+	}
+	if right.B < 190 || right.R > 10 {
+		t.Errorf("Expected blue at the right edge, got %v", right)
+	}
+}
+
+func TestFillKeepsRoleColors(t *testing.T) {
+	qr, err := New("HELLO WORLD", Medium)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	ring := color.RGBA{200, 30, 30, 255}
+	config := DefaultStyleConfig()
+	config.ModuleSize = 10
+	config.QuietZone = 40
+	WithFill(NewLinearGradient(90, ColorStop{0, color.RGBA{0, 80, 0, 255}}, ColorStop{1, color.RGBA{0, 0, 80, 255}}))(&config)
+	WithRoleColor(RoleFinderRing, ring)(&config)
+
+	img, err := qr.ToImage(config)
+	if err != nil {
+		t.Fatalf("Failed to generate image: %v", err)
+	}
+	if got := color.RGBAModel.Convert(img.At(40+35, 45)).(color.RGBA); got != ring {
+		t.Errorf("Finder ring color %v, want %v", got, ring)
+	}
+}
+
+func TestFillContrastCheck(t *testing.T) {
+	qr, err := New("HELLO WORLD", Medium)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	config := DefaultStyleConfig()
+	config.Fill = NewLinearGradient(0,
+		ColorStop{0, color.RGBA{0, 0, 0, 255}},
+		ColorStop{1, color.RGBA{255, 220, 0, 255}}, // Yellow on white
+	)
+	_, err = qr.ToImage(config)
+	if err == nil || !strings.Contains(err.Error(), "contrast") {
+		t.Errorf("Expected contrast error, got %v", err)
+	}
+
+	if ratio := ContrastRatio(color.Black, color.White); ratio < 20.9 || ratio > 21.1 {
+		t.Errorf("Black on white contrast %.2f, want 21", ratio)
+	}
+}
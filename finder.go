@@ -0,0 +1,142 @@
+package myqrcode
+
+import (
+	"image"
+	"image/draw"
+)
+
+// EyeShape selects the geometry of a finder pattern's outer ring or inner ball
+type EyeShape int
+
+const (
+	EyeSquare  EyeShape = iota // Plain square, identical to per-module squares
+	EyeRounded                 // Square with rounded corners
+	EyeCircle                  // Full circle
+	EyeLeaf                    // Two opposite corners rounded, oriented outward
+	EyeDots                    // One dot per module of the ring or ball, joined so scanners still find the pattern
+)
+
+// FinderCorner identifies one of the three finder patterns
+type FinderCorner int
+
+const (
+	FinderTopLeft FinderCorner = iota
+	FinderTopRight
+	FinderBottomLeft
+)
+
+// FinderDrawer draws each 7x7 finder pattern ("eye") as a whole shape instead
+// of module by module
+type FinderDrawer interface {
+	// Initialize sets up the drawer with the image and style configuration
+	Initialize(img *image.RGBA, config StyleConfig)
+
+	// DrawFinder renders one finder pattern
+	// box is the [x1, y1, x2, y2] pixel area of the 7x7 pattern
+	// corner tells which eye is drawn so asymmetric shapes can be mirrored
+	DrawFinder(box [4]int, corner FinderCorner)
+}
+
+// ShapeFinderDrawer draws finder patterns as vector shapes with independent
+// styles for the outer ring and the inner 3x3 ball
+type ShapeFinderDrawer struct {
+	Outer  EyeShape
+	Inner  EyeShape
+	img    *image.RGBA
+	config StyleConfig
+}
+
+func NewFinderDrawer(outer, inner EyeShape) *ShapeFinderDrawer {
+	return &ShapeFinderDrawer{Outer: outer, Inner: inner}
+}
+
+func (f *ShapeFinderDrawer) Initialize(img *image.RGBA, config StyleConfig) {
+	f.img = img
+	f.config = config
+}
+
+// dotBand is the width, in modules, of the band joining the dots of an
+// EyeDots ring
+const dotBand = 0.5
+
+func (f *ShapeFinderDrawer) DrawFinder(box [4]int, corner FinderCorner) {
+	width := box[2] - box[0]
+	m := float32(width) / 7 // Module size in pixels
+
+	rect := image.Rect(box[0], box[1], box[2], box[3])
+	height := box[3] - box[1]
+	quality := f.config.Quality
+
+	// Outer ring: a 7x7 shape with a 5x5 hole
+	var ring *image.Alpha
+	if f.Outer == EyeDots {
+		var dots []*contour
+		for i := 0; i < 7; i++ {
+			for j := 0; j < 7; j++ {
+				if i == 0 || i == 6 || j == 0 || j == 6 {
+					dots = append(dots, circle((float32(j)+0.5)*m, (float32(i)+0.5)*m, 0.5*m))
+				}
+			}
+		}
+		// A band through the dot centers keeps the ring connected, so scan
+		// lines still cross the 1:1:3:1:1 runs detectors look for
+		band := dotBand * m
+		ring = contourMask(width, height, quality,
+			append(dots, roundedRect(0.5*m-band/2, 0.5*m-band/2, 6*m+band, 6*m+band, [4]float32{})),
+			roundedRect(0.5*m+band/2, 0.5*m+band/2, 6*m-band, 6*m-band, [4]float32{}))
+	} else {
+		// The hole radius is one module smaller so both edges stay concentric
+		radius := eyeRadius(f.Outer, 7*m)
+		ring = contourMask(width, height, quality,
+			[]*contour{eyeContour(f.Outer, 0, 7*m, radius, corner)},
+			eyeContour(f.Outer, m, 5*m, max(0, radius-m), corner))
+	}
+	draw.DrawMask(f.img, rect, &image.Uniform{f.config.colorFor(RoleFinderRing)}, image.Point{}, ring, image.Point{}, draw.Over)
+
+	// Inner ball: a 3x3 shape, in its own pass so it can have its own color
+	var ball []*contour
+	if f.Inner == EyeDots {
+		for i := 2; i < 5; i++ {
+			for j := 2; j < 5; j++ {
+				ball = append(ball, circle((float32(j)+0.5)*m, (float32(i)+0.5)*m, 0.5*m))
+			}
+		}
+		// Fill the gaps between the dots so the ball reads as one 3x3 run
+		ball = append(ball, roundedRect(2.5*m, 2.5*m, 2*m, 2*m, [4]float32{}))
+	} else {
+		ball = append(ball, eyeContour(f.Inner, 2*m, 3*m, eyeRadius(f.Inner, 3*m), corner))
+	}
+	drawContours(f.img, rect, f.config.colorFor(RoleFinderCenter), quality, ball...)
+}
+
+// eyeRadius returns the corner radius for an eye part of the given size
+func eyeRadius(shape EyeShape, size float32) float32 {
+	switch shape {
+	case EyeRounded:
+		return size * 2 / 7
+	case EyeCircle:
+		return size / 2
+	case EyeLeaf:
+		return size * 3 / 7
+	}
+	return 0
+}
+
+// eyeContour builds the outline of a square eye part of the given size at
+// offset (in pixels from the finder's top-left)
+func eyeContour(shape EyeShape, offset, size, radius float32, corner FinderCorner) *contour {
+	radii := [4]float32{radius, radius, radius, radius}
+	if shape == EyeLeaf {
+		// Round the outward and inward corners, keep the other two sharp.
+		// The top-left eye is rounded at top-left and bottom-right; the other
+		// eyes mirror it.
+		switch corner {
+		case FinderTopLeft:
+			radii = [4]float32{radius, 0, radius, 0}
+		case FinderTopRight, FinderBottomLeft:
+			radii = [4]float32{0, radius, 0, radius}
+		}
+	}
+
+	return roundedRect(offset, offset, size, size, radii)
+}
@@ -0,0 +1,121 @@
+package myqrcode
+
+import (
+	"fmt"
+	"image"
+	"testing"
+)
+
+func TestFinderDrawerShapes(t *testing.T) {
+	qr, err := New("https://meet.google.com/abc-defg-hij", Medium)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	shapes := []struct {
+		name  string
+		shape EyeShape
+	}{
+		{"square", EyeSquare},
+		{"rounded", EyeRounded},
+		{"circle", EyeCircle},
+		{"leaf", EyeLeaf},
+		{"dots", EyeDots},
+	}
+
+	for _, outer := range shapes {
+		for _, inner := range shapes {
+			name := fmt.Sprintf("eyes_%s_%s", outer.name, inner.name)
+			t.Run(name, func(t *testing.T) {
+				config := DefaultStyleConfig()
+				config.ModuleSize = 10
+				config.QuietZone = 40
+				config.FinderDrawer = NewFinderDrawer(outer.shape, inner.shape)
+
+				img, err := qr.ToImage(config)
+				if err != nil {
+					t.Fatalf("Failed to generate image: %v", err)
+				}
+
+				// Sample each finder pattern in module units
+				for _, origin := range [][2]int{{0, 0}, {qr.Size - 7, 0}, {0, qr.Size - 7}} {
+					at := func(mx, my float64) bool {
+						px := config.QuietZone + int((float64(origin[0])+mx)*float64(config.ModuleSize))
+						py := config.QuietZone + int((float64(origin[1])+my)*float64(config.ModuleSize))
+						return isDark(img, px, py)
+					}
+
+					if !at(3.5, 3.5) {
+						t.Error("Expected dark inner ball center")
+					}
+					if at(1.5, 3.5) || at(3.5, 5.5) {
+						t.Error("Expected light gap between ring and ball")
+					}
+					if !at(0.5, 3.5) || !at(3.5, 6.5) {
+						t.Error("Expected dark outer ring at edge midpoints")
+					}
+
+					cornerDark := at(0.1, 0.1) && at(6.9, 0.1) && at(0.1, 6.9)
+					if outer.shape == EyeSquare && !cornerDark {
+						t.Error("Expected square outer ring corners to be dark")
+					}
+					if outer.shape == EyeCircle && cornerDark {
+						t.Error("Expected circular outer ring corners to be light")
+					}
+
+					// Dots are joined by a band so scan lines between two
+					// dots still cross a dark run, but stay visible as dots
+					if outer.shape == EyeDots && (!at(1, 0.65) || at(1, 0.9)) {
+						t.Error("Expected the ring dots joined by a band narrower than a dot")
+					}
+					if inner.shape == EyeDots && !at(3, 3) {
+						t.Error("Expected the gap between ball dots to be filled")
+					}
+				}
+
+				saveTestImage(t, img, name+".png")
+			})
+		}
+	}
+}
+
+func TestRoundedCornersKeepSquareFinders(t *testing.T) {
+	qr, err := New("HELLO WORLD", Medium)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	img, err := qr.ToImage(StyleConfig{ModuleSize: 10, QuietZone: 40, RoundedCorners: true})
+	if err != nil {
+		t.Fatalf("Failed to generate image: %v", err)
+	}
+
+	// Without a FinderDrawer the finders stay per-module squares
+	if !isDark(img, 40, 40) {
+		t.Error("Expected a square finder corner with only RoundedCorners set")
+	}
+	if !isDark(img, 40+35, 40+1) {
+		t.Error("Expected dark finder edge")
+	}
+
+	// Rounded finders need an explicit finder drawer
+	img, err = qr.ToImage(StyleConfig{ModuleSize: 10, QuietZone: 40, RoundedCorners: true,
+		FinderDrawer: NewFinderDrawer(EyeRounded, EyeRounded)})
+	if err != nil {
+		t.Fatalf("Failed to generate image: %v", err)
+	}
+	if isDark(img, 40, 40) {
+		t.Error("Expected a rounded finder corner with a FinderDrawer")
+	}
+}
+
+func isDark(img image.Image, x, y int) bool {
+	r, g, b, _ := img.At(x, y).RGBA()
+	return (r+g+b)/3 < 0x8000
+}
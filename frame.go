@@ -0,0 +1,244 @@
+package myqrcode
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// FrameShape selects the border drawn around a framed code
+type FrameShape int
+
+const (
+	FrameRoundedBox   FrameShape = iota // Rounded box with the caption in a band at the bottom
+	FrameSpeechBubble                   // Bubble around the code with a tail pointing at the caption
+	FrameTicketStub                     // Ticket with a perforated stub holding the caption
+)
+
+// Frame surrounds the rendered code with a border and a caption such as
+// "SCAN ME". The frame is laid out around the full image, so the quiet zone
+// is never covered, and keeps at least frameMargin modules of background
+// between the modules and the border.
+type Frame struct {
+	Shape   FrameShape
+	Caption string
+
+	Font      *opentype.Font // Defaults to Go Bold
+	FontSize  float64        // In pixels; 0 sizes the caption to the code
+	Color     color.Color    // Border and band color; defaults to ForegroundColor
+	TextColor color.Color    // Defaults to BackgroundColor on a band (black or white if that isn't opaque), Color otherwise
+	Thickness int            // Border width in pixels; 0 picks one from the code size
+}
+
+// frameMargin is the light margin, in modules, kept between the modules and
+// the frame, the quiet zone the QR specification asks for
+const frameMargin = 4
+
+// goBold is the default caption font, parsed on first use
+var goBold = sync.OnceValues(func() (*opentype.Font, error) {
+	return opentype.Parse(gobold.TTF)
+})
+
+// frameLayout holds the pixel geometry of a framed code
+type frameLayout struct {
+	width, height int
+	code          image.Point     // Top-left of the code image
+	body          image.Rectangle // Box drawn around the code
+	caption       image.Rectangle // Area the caption is centered in
+	thickness     int
+	perforation   int // Ticket stubs: height of the perforation line
+	notch         int // Ticket stubs: radius of the side notches
+}
+
+func (f *Frame) layout(codeSize, captionHeight int) frameLayout {
+	t := f.Thickness
+	if t <= 0 {
+		t = max(2, codeSize/60)
+	}
+
+	l := frameLayout{thickness: t, width: codeSize + 2*t}
+	l.code = image.Pt(t, t)
+	l.body = image.Rect(0, 0, l.width, codeSize+2*t)
+
+	switch f.Shape {
+	case FrameSpeechBubble:
+		// The tail hangs below the bubble, the caption sits under it
+		tail := captionHeight / 2
+		l.caption = image.Rect(0, l.body.Max.Y+tail, l.width, l.body.Max.Y+tail+captionHeight)
+	case FrameTicketStub:
+		// A strip between code and caption holds the perforation; the notches
+		// fill it without reaching the code
+		strip := t + max(2*t, captionHeight/3)
+		l.body.Max.Y += strip - t + captionHeight
+		l.perforation = codeSize + t + strip/2
+		l.notch = strip / 2
+		l.caption = image.Rect(t, codeSize+t+strip, l.width-t, l.body.Max.Y-t)
+	default:
+		// The band is part of the box
+		l.body.Max.Y += captionHeight
+		l.caption = image.Rect(t, codeSize+2*t, l.width-t, l.body.Max.Y-t)
+	}
+
+	l.height = max(l.body.Max.Y, l.caption.Max.Y)
+	return l
+}
+
+// applyFrame draws the code image inside the frame and returns the result.
+// pad widens the code's background on every side, in pixels, where its quiet
+// zone is narrower than frameMargin modules.
+func applyFrame(code *image.RGBA, frame *Frame, config StyleConfig, pad int) (*image.RGBA, error) {
+	if pad > 0 {
+		padded := image.NewRGBA(image.Rect(0, 0, code.Bounds().Dx()+2*pad, code.Bounds().Dy()+2*pad))
+		draw.Draw(padded, padded.Bounds(), &image.Uniform{config.BackgroundColor}, image.Point{}, draw.Src)
+		draw.Draw(padded, code.Bounds().Add(image.Pt(pad, pad)), code, code.Bounds().Min, draw.Src)
+		code = padded
+	}
+	codeSize := code.Bounds().Dx()
+
+	ttf := frame.Font
+	if ttf == nil {
+		var err error
+		if ttf, err = goBold(); err != nil {
+			return nil, fmt.Errorf("failed to load caption font: %w", err)
+		}
+	}
+
+	fontSize := frame.FontSize
+	if fontSize <= 0 {
+		fontSize = math.Max(10, float64(codeSize)/9)
+	}
+
+	// Shrink the caption until it fits the frame width
+	var face font.Face
+	for {
+		var err error
+		face, err = opentype.NewFace(ttf, &opentype.FaceOptions{Size: fontSize, DPI: 72, Hinting: font.HintingFull})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create caption face: %w", err)
+		}
+		if frame.Caption == "" || font.MeasureString(face, frame.Caption).Ceil() <= codeSize*9/10 || fontSize <= 6 {
+			break
+		}
+		face.Close()
+		fontSize *= 0.9
+	}
+	defer face.Close()
+
+	captionHeight := 0
+	if frame.Caption != "" {
+		captionHeight = int(fontSize * 1.8)
+	}
+	l := frame.layout(codeSize, captionHeight)
+
+	frameColor := frame.Color
+	if frameColor == nil {
+		frameColor = config.ForegroundColor
+	}
+	textColor := frame.TextColor
+	if textColor == nil {
+		textColor = config.BackgroundColor
+		if frame.Shape == FrameSpeechBubble {
+			textColor = frameColor
+		} else if _, _, _, a := textColor.RGBA(); a < 0xffff {
+			// A transparent background would punch the caption out of the band
+			textColor = color.White
+			if relativeLuminance(opaque(frameColor)) > contrastMidpoint(0, 1) {
+				textColor = color.Black
+			}
+		}
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, l.width, l.height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{config.BackgroundColor}, image.Point{}, draw.Src)
+
+	t := float32(l.thickness)
+	body := l.body
+	bw, bh := float32(body.Dx()), float32(body.Dy())
+	radius := 3 * t
+
+	switch frame.Shape {
+	case FrameTicketStub:
+		// Notches on both sides at the perforation between code and stub
+		y := float32(l.perforation)
+		notch := float32(l.notch)
+		drawContours(img, body, frameColor, config.Quality, ticketContour(bw, bh, radius, y, notch))
+
+		dash := int(max(2, t))
+		for x := int(notch) + dash; x < l.width-int(notch)-dash; x += 3 * dash {
+			rect := image.Rect(x, int(y)-dash/4-1, x+2*dash, int(y)+dash/4+1)
+			draw.Draw(img, rect, &image.Uniform{config.BackgroundColor}, image.Point{}, draw.Src)
+		}
+
+	case FrameSpeechBubble:
+		drawContours(img, body, frameColor, config.Quality, roundedRect(0, 0, bw, bh, [4]float32{radius, radius, radius, radius}))
+
+		tail := float32(l.caption.Min.Y - body.Max.Y)
+		cx := bw / 2
+		area := image.Rect(0, body.Max.Y-l.thickness, l.width, l.caption.Min.Y)
+		drawContours(img, area, frameColor, config.Quality, polygon(
+			point{cx - tail, 0}, point{cx + tail, 0}, point{cx, t + tail},
+		))
+
+	default:
+		drawContours(img, body, frameColor, config.Quality, roundedRect(0, 0, bw, bh, [4]float32{radius, radius, radius, radius}))
+	}
+
+	// The code keeps its own background and quiet zone
+	draw.Draw(img, code.Bounds().Add(l.code), code, image.Point{}, draw.Src)
+
+	if frame.Caption != "" {
+		drawCaption(img, face, frame.Caption, l.caption, textColor)
+	}
+
+	return img, nil
+}
+
+// drawCaption centers a single line of text in area
+func drawCaption(img *image.RGBA, face font.Face, text string, area image.Rectangle, c color.Color) {
+	metrics := face.Metrics()
+	width := font.MeasureString(face, text)
+
+	x := fixed.I(area.Min.X) + (fixed.I(area.Dx())-width)/2
+	textHeight := metrics.Ascent + metrics.Descent
+	y := fixed.I(area.Min.Y) + (fixed.I(area.Dy())-textHeight)/2 + metrics.Ascent
+
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  &image.Uniform{c},
+		Face: face,
+		Dot:  fixed.Point26_6{X: x, Y: y},
+	}
+	d.DrawString(text)
+}
+
+// ticketContour builds a w x h ticket outline with rounded corners and a
+// half-circle notch of radius n on each side at height y
+func ticketContour(w, h, r, y, n float32) *contour {
+	k := float32(kappa)
+	c := &contour{start: point{r, 0}}
+
+	c.lineTo(w-r, 0)
+	c.cubeTo(w-r+r*k, 0, w, r-r*k, w, r)
+	c.lineTo(w, y-n)
+	c.cubeTo(w-n*k, y-n, w-n, y-n*k, w-n, y)
+	c.cubeTo(w-n, y+n*k, w-n*k, y+n, w, y+n)
+	c.lineTo(w, h-r)
+	c.cubeTo(w, h-r+r*k, w-r+r*k, h, w-r, h)
+	c.lineTo(r, h)
+	c.cubeTo(r-r*k, h, 0, h-r+r*k, 0, h-r)
+	c.lineTo(0, y+n)
+	c.cubeTo(n*k, y+n, n, y+n*k, n, y)
+	c.cubeTo(n, y-n*k, n*k, y-n, 0, y-n)
+	c.lineTo(0, r)
+	c.cubeTo(0, r-r*k, r-r*k, 0, r, 0)
+
+	return c
+}
@@ -0,0 +1,176 @@
+package myqrcode
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestFrames(t *testing.T) {
+	qr, err := New("https://meet.google.com/abc-defg-hij", Medium)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	config := DefaultStyleConfig()
+	config.ModuleSize = 10
+	config.QuietZone = 40
+	config.ModuleDrawer = NewCircleModuleDrawer()
+
+	plain, err := qr.ToImage(config)
+	if err != nil {
+		t.Fatalf("Failed to generate image: %v", err)
+	}
+	codeSize := plain.Bounds().Dx()
+
+	shapes := map[string]FrameShape{
+		"rounded_box":   FrameRoundedBox,
+		"speech_bubble": FrameSpeechBubble,
+		"ticket_stub":   FrameTicketStub,
+	}
+
+	for name, shape := range shapes {
+		t.Run(name, func(t *testing.T) {
+			framedConfig := config
+			WithFrame(shape, "SCAN ME")(&framedConfig)
+			framedConfig.Frame.Color = color.RGBA{30, 60, 160, 255}
+
+			img, err := qr.ToImage(framedConfig)
+			if err != nil {
+				t.Fatalf("Failed to generate framed image: %v", err)
+			}
+
+			bounds := img.Bounds()
+			if bounds.Dx() <= codeSize || bounds.Dy() <= codeSize+20 {
+				t.Fatalf("Framed image %v too small for a %dpx code and caption", bounds, codeSize)
+			}
+
+			// The code and its quiet zone are copied unchanged
+			offset := findCode(img, plain)
+			if offset == nil {
+				t.Fatal("Code with its quiet zone not found intact in the framed image")
+			}
+
+			// The caption is drawn below the code
+			textPixels := 0
+			for y := offset.Y + codeSize; y < bounds.Max.Y; y++ {
+				for x := bounds.Min.X; x < bounds.Max.X; x++ {
+					c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+					if c.R > 200 && c.G > 200 && c.B > 200 || shape == FrameSpeechBubble && c.B > 150 && c.R < 40 {
+						textPixels++
+					}
+				}
+			}
+			if textPixels < 100 {
+				t.Errorf("Expected caption pixels below the code, found %d", textPixels)
+			}
+
+			saveTestImage(t, img, "test_frame_"+name+".png")
+		})
+	}
+}
+
+func TestFrameCaptionShrinksToFit(t *testing.T) {
+	qr, err := New("HELLO", Low)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	config := StyleConfig{ModuleSize: 4, QuietZone: 16, BackgroundColor: color.White}
+	WithFrame(FrameRoundedBox, strings.Repeat("https://example.com/", 3))(&config)
+
+	img, err := qr.ToImage(config)
+	if err != nil {
+		t.Fatalf("Failed to generate framed image: %v", err)
+	}
+
+	// No caption pixel touches the frame's side borders, below the code
+	// (116px plus a 2px border) and above the rounded bottom corners
+	bounds := img.Bounds()
+	for y := 120; y < bounds.Max.Y-6; y++ {
+		for _, x := range []int{bounds.Min.X + 1, bounds.Max.X - 2} {
+			if !isDark(img, x, y) {
+				t.Fatalf("Caption overflows the band at (%d, %d)", x, y)
+			}
+		}
+	}
+}
+
+func TestFrameMarginAndTransparentCaption(t *testing.T) {
+	qr, err := New("HELLO", Low)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	// A half-module quiet zone is widened to four modules inside the frame
+	config := StyleConfig{ModuleSize: 8, QuietZone: 4}
+	WithTransparentBackground()(&config)
+	WithFrame(FrameRoundedBox, "SCAN ME")(&config)
+	config.Frame.Thickness = 3
+
+	img, err := qr.ToImage(config)
+	if err != nil {
+		t.Fatalf("Failed to generate framed image: %v", err)
+	}
+	if want := qr.Size*8 + 2*4*8 + 2*3; img.Bounds().Dx() != want {
+		t.Errorf("Expected a %dpx wide frame, got %d", want, img.Bounds().Dx())
+	}
+	for x := 3; x < 3+4*8; x++ {
+		if _, _, _, a := img.At(x, 3+4*8+3).RGBA(); a != 0 {
+			t.Fatalf("Expected background between the frame and the modules at x=%d", x)
+		}
+	}
+
+	// The caption stays visible on the band instead of being punched out
+	rgba := img.(*image.RGBA)
+	light := 0
+	for y := 3 + qr.Size*8 + 2*4*8 + 3; y < rgba.Bounds().Max.Y; y++ {
+		for x := 0; x < rgba.Bounds().Max.X; x++ {
+			c := rgba.RGBAAt(x, y)
+			if c.A == 0xff && c.R > 200 {
+				light++
+			}
+			if c.A == 0 && x > 8 && x < rgba.Bounds().Max.X-8 && y < rgba.Bounds().Max.Y-8 {
+				t.Fatalf("Caption punched a hole in the band at (%d, %d)", x, y)
+			}
+		}
+	}
+	if light < 50 {
+		t.Errorf("Expected an opaque light caption, found %d pixels", light)
+	}
+}
+
+// findCode locates code inside img by comparing pixels
+func findCode(img, code image.Image) *image.Point {
+	b, cb := img.Bounds(), code.Bounds()
+	for oy := b.Min.Y; oy+cb.Dy() <= b.Max.Y; oy++ {
+		for ox := b.Min.X; ox+cb.Dx() <= b.Max.X; ox++ {
+			if samePixels(img, code, ox, oy) {
+				return &image.Point{ox, oy}
+			}
+		}
+	}
+	return nil
+}
+
+func samePixels(img, code image.Image, ox, oy int) bool {
+	cb := code.Bounds()
+	for y := cb.Min.Y; y < cb.Max.Y; y++ {
+		for x := cb.Min.X; x < cb.Max.X; x++ {
+			if img.At(ox+x, oy+y) != code.At(x, y) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -6,3 +6,5 @@ require (
 	golang.org/x/image v0.30.0
 	rsc.io/qr v0.2.0
 )
+
+require golang.org/x/text v0.28.0 // indirect
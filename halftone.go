@@ -0,0 +1,204 @@
+package myqrcode
+
+import (
+	"image"
+	"image/draw"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// SymbolAwareDrawer is implemented by module drawers that need the whole
+// symbol before drawing, e.g. to map a picture onto the module grid.
+// ToImage calls Prepare after Initialize.
+type SymbolAwareDrawer interface {
+	ModuleDrawer
+
+	// Prepare receives the encoded symbol and its pixel area (without quiet zone)
+	Prepare(qr *QRCode, symbol image.Rectangle)
+}
+
+// ReadabilityMargin summarizes how clearly a halftone symbol still reads as
+// its modules. Agreement is the share of a module's subpixels that have the
+// module's own color.
+type ReadabilityMargin struct {
+	CenterRatio   float64 // Share of each module's width fixed to the code bit
+	MinAgreement  float64 // Worst module
+	MeanAgreement float64
+	Conflicts     int // Modules where most subpixels have the opposite color
+}
+
+// HalftoneModuleDrawer blends a picture into the modules. Each module is split
+// into Subdivisions x Subdivisions subpixels: the center one always carries
+// the code bit, the others follow a dithered version of the picture.
+// Function patterns (timing, alignment, format and version information) are
+// drawn solid.
+type HalftoneModuleDrawer struct {
+	BaseModuleDrawer
+	Picture      image.Image
+	Subdivisions int
+
+	size   int      // Modules per side
+	bits   [][]bool // Dithered subpixels, [row][col]
+	margin ReadabilityMargin
+}
+
+func NewHalftoneModuleDrawer(picture image.Image, subdivisions int) *HalftoneModuleDrawer {
+	if subdivisions < 3 {
+		subdivisions = 3
+	}
+	if subdivisions%2 == 0 {
+		subdivisions++ // A center subpixel needs an odd count
+	}
+	return &HalftoneModuleDrawer{Picture: picture, Subdivisions: subdivisions}
+}
+
+// Margin returns the readability margin of the last prepared symbol
+func (h *HalftoneModuleDrawer) Margin() ReadabilityMargin {
+	return h.margin
+}
+
+func (h *HalftoneModuleDrawer) Prepare(qr *QRCode, symbol image.Rectangle) {
+	sub := h.Subdivisions
+	n := qr.Size * sub
+	h.size = qr.Size
+
+	// Each subpixel's target: dithered picture, or forced to the code bit
+	forced := make([][]int8, n) // 1 dark, -1 light, 0 free
+	for i := range forced {
+		forced[i] = make([]int8, n)
+	}
+	roles := qr.ModuleRoles()
+	for y := 0; y < qr.Size; y++ {
+		for x := 0; x < qr.Size; x++ {
+			value := int8(-1)
+			if qr.Matrix[y][x] {
+				value = 1
+			}
+			if roles[y][x] != RoleData {
+				for dy := 0; dy < sub; dy++ {
+					for dx := 0; dx < sub; dx++ {
+						forced[y*sub+dy][x*sub+dx] = value
+					}
+				}
+			} else {
+				forced[y*sub+sub/2][x*sub+sub/2] = value
+			}
+		}
+	}
+
+	h.bits = ditherPicture(h.Picture, n, forced)
+	h.margin = halftoneMargin(qr, h.bits, sub)
+}
+
+// DrawModule draws nothing: the subpixels are looked up by the module's
+// position, which only DrawModuleAt receives
+func (h *HalftoneModuleDrawer) DrawModule(box [4]int, isActive bool, neighbors *ActiveWithNeighbors) {
+}
+
+func (h *HalftoneModuleDrawer) DrawModuleAt(box [4]int, mx, my int, isActive bool, neighbors *ActiveWithNeighbors) {
+	if h.bits == nil || mx < 0 || my < 0 || mx >= h.size || my >= h.size {
+		return
+	}
+
+	width := box[2] - box[0]
+	sub := h.Subdivisions
+
+	fg := &image.Uniform{h.config.ForegroundColor}
+	for dy := 0; dy < sub; dy++ {
+		for dx := 0; dx < sub; dx++ {
+			if !h.bits[my*sub+dy][mx*sub+dx] {
+				continue
+			}
+			rect := image.Rect(
+				box[0]+dx*width/sub, box[1]+dy*width/sub,
+				box[0]+(dx+1)*width/sub, box[1]+(dy+1)*width/sub,
+			)
+			draw.Draw(h.img, rect, fg, image.Point{}, draw.Over)
+		}
+	}
+}
+
+// ditherPicture scales the picture to n x n and Floyd-Steinberg dithers it to
+// dark (true) and light subpixels. Forced subpixels take their fixed value and
+// pass the difference on, so the picture's tone is kept around them.
+func ditherPicture(picture image.Image, n int, forced [][]int8) [][]bool {
+	gray := image.NewGray(image.Rect(0, 0, n, n))
+	if picture != nil {
+		xdraw.CatmullRom.Scale(gray, gray.Bounds(), picture, picture.Bounds(), xdraw.Src, nil)
+	} else {
+		draw.Draw(gray, gray.Bounds(), image.White, image.Point{}, draw.Src)
+	}
+
+	level := make([][]float64, n)
+	for y := range level {
+		level[y] = make([]float64, n)
+		for x := range level[y] {
+			level[y][x] = float64(gray.GrayAt(x, y).Y) / 255
+		}
+	}
+
+	bits := make([][]bool, n)
+	for y := 0; y < n; y++ {
+		bits[y] = make([]bool, n)
+		for x := 0; x < n; x++ {
+			old := level[y][x]
+			dark := old < 0.5
+			switch forced[y][x] {
+			case 1:
+				dark = true
+			case -1:
+				dark = false
+			}
+			bits[y][x] = dark
+
+			value := 1.0
+			if dark {
+				value = 0
+			}
+			diffuse := func(x, y int, weight float64) {
+				if x >= 0 && x < n && y < n {
+					level[y][x] += (old - value) * weight
+				}
+			}
+			diffuse(x+1, y, 7.0/16)
+			diffuse(x-1, y+1, 3.0/16)
+			diffuse(x, y+1, 5.0/16)
+			diffuse(x+1, y+1, 1.0/16)
+		}
+	}
+	return bits
+}
+
+// halftoneMargin measures how many subpixels of each data module agree with its bit
+func halftoneMargin(qr *QRCode, bits [][]bool, sub int) ReadabilityMargin {
+	margin := ReadabilityMargin{CenterRatio: 1 / float64(sub), MinAgreement: 1}
+	roles := qr.ModuleRoles()
+
+	total, modules := 0.0, 0
+	for y := 0; y < qr.Size; y++ {
+		for x := 0; x < qr.Size; x++ {
+			if roles[y][x] != RoleData {
+				continue
+			}
+			agree := 0
+			for dy := 0; dy < sub; dy++ {
+				for dx := 0; dx < sub; dx++ {
+					if bits[y*sub+dy][x*sub+dx] == qr.Matrix[y][x] {
+						agree++
+					}
+				}
+			}
+			share := float64(agree) / float64(sub*sub)
+			margin.MinAgreement = min(margin.MinAgreement, share)
+			if share < 0.5 {
+				margin.Conflicts++
+			}
+			total += share
+			modules++
+		}
+	}
+	if modules > 0 {
+		margin.MeanAgreement = total / float64(modules)
+	}
+	return margin
+}
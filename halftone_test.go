@@ -0,0 +1,91 @@
+package myqrcode
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// createTestPortrait makes a smooth picture: a dark disc on a light gradient
+func createTestPortrait(size int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	c := float64(size) / 2
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			v := 90 + 150*x/size
+			dx, dy := float64(x)-c, float64(y)-c*1.1
+			if dx*dx+dy*dy < c*c*0.36 {
+				v = 40
+			}
+			img.SetGray(x, y, color.Gray{uint8(v)})
+		}
+	}
+	return img
+}
+
+func TestHalftoneDrawer(t *testing.T) {
+	qr, err := New("https://meet.google.com/abc-defg-hij", High)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	drawer := NewHalftoneModuleDrawer(createTestPortrait(300), 3)
+	config := DefaultStyleConfig()
+	config.ModuleSize = 9
+	config.QuietZone = 36
+	config.ModuleDrawer = drawer
+
+	img, err := qr.ToImage(config)
+	if err != nil {
+		t.Fatalf("Failed to generate image: %v", err)
+	}
+
+	// The center subpixel of every module carries its bit
+	roles := qr.ModuleRoles()
+	for y := 0; y < qr.Size; y++ {
+		for x := 0; x < qr.Size; x++ {
+			px := config.QuietZone + x*config.ModuleSize + config.ModuleSize/2
+			py := config.QuietZone + y*config.ModuleSize + config.ModuleSize/2
+			if isDark(img, px, py) != qr.Matrix[y][x] {
+				t.Fatalf("Module (%d, %d) center does not match its bit", x, y)
+			}
+		}
+	}
+
+	// Data modules show the picture: some disagree with their bit at the edges
+	margin := drawer.Margin()
+	if margin.CenterRatio != 1.0/3 {
+		t.Errorf("Center ratio %.2f, want 1/3", margin.CenterRatio)
+	}
+	if margin.MeanAgreement >= 1 || margin.MinAgreement >= margin.MeanAgreement {
+		t.Errorf("Expected the picture to show in the modules, got %+v", margin)
+	}
+	t.Logf("Halftone margin: %+v", margin)
+
+	// Function patterns stay solid
+	for y := 0; y < qr.Size; y++ {
+		for x := 0; x < qr.Size; x++ {
+			if roles[y][x] != RoleTiming {
+				continue
+			}
+			px := config.QuietZone + x*config.ModuleSize + 1
+			py := config.QuietZone + y*config.ModuleSize + 1
+			if isDark(img, px, py) != qr.Matrix[y][x] {
+				t.Fatalf("Timing module (%d, %d) is not solid", x, y)
+			}
+		}
+	}
+
+	saveTestImage(t, img, "test_halftone.png")
+}
+
+func TestNewHalftoneModuleDrawerSubdivisions(t *testing.T) {
+	for _, tc := range []struct{ in, want int }{{0, 3}, {3, 3}, {4, 5}, {5, 5}} {
+		if got := NewHalftoneModuleDrawer(nil, tc.in).Subdivisions; got != tc.want {
+			t.Errorf("Subdivisions %d: got %d, want %d", tc.in, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package myqrcode
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// maxSupersampledSize bounds the side of the intermediate image rendered
+// for a fractional module size
+const maxSupersampledSize = 4096
+
+// imageLayout returns the module size and quiet zone, in pixels, that fill
+// config.ImageSize. The quiet zone stays in pixels when set and is four
+// modules otherwise.
+func (qr *QRCode) imageLayout(config StyleConfig) (module, quiet float64, err error) {
+	size := float64(config.ImageSize)
+	if config.QuietZone > 0 {
+		quiet = float64(config.QuietZone)
+		module = (size - 2*quiet) / float64(qr.Size)
+	} else {
+		module = size / float64(qr.Size+8)
+		quiet = 4 * module
+	}
+
+	if config.SnapModules {
+		module = math.Floor(module)
+		quiet = (size - module*float64(qr.Size)) / 2
+	}
+
+	if module < 1 {
+		return 0, 0, fmt.Errorf("image size %d leaves less than one pixel per module for a %d-module symbol", config.ImageSize, qr.Size)
+	}
+	return module, quiet, nil
+}
+
+// toSizedImage renders the code at exactly config.ImageSize pixels square.
+// Whole-pixel layouts are drawn directly. Otherwise the code is drawn with
+// a larger whole module size and area-averaged down, so every edge gets the
+// coverage it has at the fractional position.
+func (qr *QRCode) toSizedImage(config StyleConfig) (image.Image, error) {
+	module, quiet, err := qr.imageLayout(config)
+	if err != nil {
+		return nil, err
+	}
+	size := config.ImageSize
+
+	frame := config.Frame
+	render := config
+	render.ImageSize = 0
+	render.Frame = nil
+
+	var img *image.RGBA
+	if module == math.Floor(module) {
+		// Whole modules: an odd leftover pixel goes to the right and bottom.
+		// The code is then rendered a pixel wider on each side and cropped,
+		// so its own background covers that pixel too.
+		render.ModuleSize = int(module)
+		render.QuietZone = int(quiet)
+		offset := 0
+		if render.ModuleSize*qr.Size+2*render.QuietZone < size {
+			render.QuietZone++
+			offset = 1
+		}
+		code, err := qr.ToImage(render)
+		if err != nil {
+			return nil, err
+		}
+		img = image.NewRGBA(image.Rect(0, 0, size, size))
+		draw.Draw(img, img.Bounds(), code, image.Pt(offset, offset), draw.Src)
+	} else {
+		scale := float64(max(1, min(AntialiasingFactor, maxSupersampledSize/size)))
+		render.ModuleSize = int(math.Ceil(module * scale))
+		step := float64(render.ModuleSize) / module // Intermediate pixels per output pixel
+		render.QuietZone = int(math.Ceil(quiet * step))
+
+		code, err := qr.ToImage(render)
+		if err != nil {
+			return nil, err
+		}
+		img = resampleArea(code.(*image.RGBA), size, step, float64(render.QuietZone)-quiet*step)
+	}
+
+	if frame != nil {
+		config.ForegroundColor, config.BackgroundColor = imageColors(config)
+		return applyFrame(img, frame, config, max(0, int(math.Ceil(frameMargin*module-quiet))))
+	}
+	return img, nil
+}
+
+// imageColors returns the foreground and background colors ToImage
+// paints with, after defaults and inversion
+func imageColors(config StyleConfig) (fg, bg color.Color) {
+	fg, bg = config.ForegroundColor, config.BackgroundColor
+	if fg == nil {
+		fg = color.RGBA{0, 0, 0, 255}
+	}
+	if bg == nil {
+		bg = color.RGBA{255, 255, 255, 255}
+	}
+	if config.Inverted {
+		fg, bg = bg, fg
+	}
+	return fg, bg
+}
+
+// areaWeight is the share of one source pixel in an output pixel
+type areaWeight struct {
+	index  int
+	weight float64
+}
+
+// areaWeights returns, for each of n output pixels, the source pixels its
+// footprint [origin+i*step, origin+(i+1)*step) overlaps and by how much
+func areaWeights(n int, step, origin float64, limit int) [][]areaWeight {
+	weights := make([][]areaWeight, n)
+	for i := range weights {
+		lo, hi := origin+float64(i)*step, origin+float64(i+1)*step
+		for s := max(0, int(math.Floor(lo))); s < limit && float64(s) < hi; s++ {
+			if w := math.Min(hi, float64(s+1)) - math.Max(lo, float64(s)); w > 0 {
+				weights[i] = append(weights[i], areaWeight{s, w})
+			}
+		}
+	}
+	return weights
+}
+
+// resampleArea returns a size×size image where each pixel averages the
+// source area it covers: a step×step square starting at origin in both
+// axes. Averaging premultiplied colors keeps edges free of dark fringes.
+func resampleArea(src *image.RGBA, size int, step, origin float64) *image.RGBA {
+	b := src.Bounds()
+	xs := areaWeights(size, step, origin, b.Dx())
+	ys := areaWeights(size, step, origin, b.Dy())
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y, wy := range ys {
+		for x, wx := range xs {
+			var sum [4]float64
+			total := 0.0
+			for _, v := range wy {
+				row := src.PixOffset(b.Min.X, b.Min.Y+v.index)
+				for _, h := range wx {
+					w := v.weight * h.weight
+					p := src.Pix[row+4*h.index : row+4*h.index+4]
+					sum[0] += w * float64(p[0])
+					sum[1] += w * float64(p[1])
+					sum[2] += w * float64(p[2])
+					sum[3] += w * float64(p[3])
+					total += w
+				}
+			}
+			if total == 0 {
+				continue
+			}
+			i := dst.PixOffset(x, y)
+			for c := range sum {
+				dst.Pix[i+c] = uint8(sum[c]/total + 0.5)
+			}
+		}
+	}
+	return dst
+}
@@ -0,0 +1,142 @@
+package myqrcode
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestImageSize(t *testing.T) {
+	qr, err := New("https://meet.google.com/abc-defg-hij", High)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		config StyleConfig
+	}{
+		{"fractional", StyleConfig{ImageSize: 300}},
+		{"fractional_circles", StyleConfig{ImageSize: 300, ModuleDrawer: NewCircleModuleDrawer()}},
+		{"quiet_zone", StyleConfig{ImageSize: 250, QuietZone: 10}},
+		{"snapped", StyleConfig{ImageSize: 300, SnapModules: true}},
+		{"whole", StyleConfig{ImageSize: (qr.Size + 8) * 6}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			img, err := qr.ToImage(tc.config)
+			if err != nil {
+				t.Fatalf("Failed to generate image: %v", err)
+			}
+			if img.Bounds() != image.Rect(0, 0, tc.config.ImageSize, tc.config.ImageSize) {
+				t.Fatalf("Expected a %dx%d image, got %v", tc.config.ImageSize, tc.config.ImageSize, img.Bounds())
+			}
+
+			module, quiet, err := qr.imageLayout(tc.config)
+			if err != nil {
+				t.Fatalf("Failed to lay out image: %v", err)
+			}
+			if tc.config.SnapModules {
+				quiet = float64(int(quiet))
+			}
+			for y := 0; y < qr.Size; y++ {
+				for x := 0; x < qr.Size; x++ {
+					cx := int(quiet + (float64(x)+0.5)*module)
+					cy := int(quiet + (float64(y)+0.5)*module)
+					if isDark(img, cx, cy) != qr.Matrix[y][x] {
+						t.Fatalf("Module (%d, %d) center does not match its bit", x, y)
+					}
+				}
+			}
+
+			saveTestImage(t, img, "test_image_size_"+tc.name+".png")
+		})
+	}
+}
+
+func TestSnapModules(t *testing.T) {
+	qr, err := New("https://meet.google.com/abc-defg-hij", High)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	// 301 pixels over 37 modules and the quiet zone snaps to 6 pixel
+	// modules and a 39.5 pixel quiet zone, split 39 and 40
+	if qr.Size != 37 {
+		t.Fatalf("Expected a 37-module symbol, got %d", qr.Size)
+	}
+	config := StyleConfig{ImageSize: 301, SnapModules: true}
+	module, quiet, err := qr.imageLayout(config)
+	if err != nil || module != 6 || quiet != 39.5 {
+		t.Fatalf("Expected 6 pixel modules and a 39.5 pixel quiet zone, got %v, %v, %v", module, quiet, err)
+	}
+
+	img, err := qr.ToImage(config)
+	if err != nil {
+		t.Fatalf("Failed to generate image: %v", err)
+	}
+
+	// Every pixel is fully dark or light: no module edge falls inside a pixel
+	rgba := img.(*image.RGBA)
+	for y := 0; y < 301; y++ {
+		for x := 0; x < 301; x++ {
+			c := rgba.RGBAAt(x, y)
+			if c != (color.RGBA{0, 0, 0, 255}) && c != (color.RGBA{255, 255, 255, 255}) {
+				t.Fatalf("Expected only black and white pixels, got %v at (%d, %d)", c, x, y)
+			}
+		}
+	}
+
+	// The symbol starts 39 pixels in, with the odd pixel at the far side
+	if !isDark(img, 39, 39) || isDark(img, 38, 39) || isDark(img, 39+37*6, 39) {
+		t.Error("Expected the symbol to span pixels 39 to 260")
+	}
+
+	// The odd pixel shows the background image, not the flat background color
+	green := color.RGBA{40, 160, 80, 255}
+	picture := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	draw.Draw(picture, picture.Bounds(), image.NewUniform(green), image.Point{}, draw.Src)
+	config.Background = &BackgroundImage{Image: picture, Scaling: BackgroundFill}
+	img, err = qr.ToImage(config)
+	if err != nil {
+		t.Fatalf("Failed to generate image: %v", err)
+	}
+	if got := img.At(300, 300); !sameColor(got, green) {
+		t.Errorf("Expected the background image in the leftover corner, got %v", got)
+	}
+	if !sameColor(img.At(39, 39), color.Black) || !sameColor(img.At(39+37*6, 39), green) {
+		t.Error("Expected the symbol to stay at pixels 39 to 260 over a background image")
+	}
+}
+
+func TestImageSizeErrors(t *testing.T) {
+	qr, err := New("https://meet.google.com/abc-defg-hij", High)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	if _, err := qr.ToImage(StyleConfig{ImageSize: 30}); err == nil {
+		t.Error("Expected an error for an image smaller than one pixel per module")
+	}
+	if _, err := qr.ToImage(StyleConfig{ImageSize: 60, QuietZone: 20, SnapModules: true}); err == nil {
+		t.Error("Expected an error when snapping leaves no whole pixel per module")
+	}
+
+	// A frame wraps the sized code
+	img, err := qr.ToImage(StyleConfig{ImageSize: 300, Frame: &Frame{Caption: "SCAN ME"}})
+	if err != nil {
+		t.Fatalf("Failed to generate framed image: %v", err)
+	}
+	if img.Bounds().Dx() <= 300 || img.Bounds().Dy() <= 300 {
+		t.Errorf("Expected the frame around the 300 pixel code, got %v", img.Bounds())
+	}
+}
@@ -0,0 +1,120 @@
+package myqrcode
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestInvertedDrawers(t *testing.T) {
+	qr, err := New("https://meet.google.com/abc-defg-hij", Medium)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	drawers := map[string]ModuleDrawer{
+		"square":  NewSquareModuleDrawer(),
+		"circle":  NewCircleModuleDrawer(),
+		"gapped":  NewGappedCircleModuleDrawer(0.9),
+		"rounded": NewRoundedModuleDrawer(1.0),
+		"liquid":  NewLiquidModuleDrawer(1.0),
+	}
+
+	const ms, qz = 10, 40
+	for name, drawer := range drawers {
+		t.Run(name, func(t *testing.T) {
+			config := DefaultStyleConfig()
+			config.ModuleSize = ms
+			config.QuietZone = qz
+			config.ModuleDrawer = drawer
+			config.FinderDrawer = NewFinderDrawer(EyeRounded, EyeRounded)
+			WithInverted()(&config)
+
+			img, err := qr.ToImage(config)
+			if err != nil {
+				t.Fatalf("Failed to generate image: %v", err)
+			}
+
+			// Quiet zone is dark all around
+			bounds := img.Bounds()
+			for _, p := range []image.Point{{0, 0}, {bounds.Max.X - 1, bounds.Max.Y / 2}, {bounds.Max.X / 2, bounds.Max.Y - 1}} {
+				if !isDark(img, p.X, p.Y) {
+					t.Errorf("Expected dark quiet zone at %v", p)
+				}
+			}
+
+			// Dark modules are light and light modules dark. Finder corners
+			// are rounded away, so only data modules are sampled.
+			roles := qr.ModuleRoles()
+			for y := 0; y < qr.Size; y++ {
+				for x := 0; x < qr.Size; x++ {
+					if roles[y][x] != RoleData {
+						continue
+					}
+					if isDark(img, qz+x*ms+ms/2, qz+y*ms+ms/2) == qr.Matrix[y][x] {
+						t.Fatalf("Module (%d, %d) is not inverted", x, y)
+					}
+				}
+			}
+
+			saveTestImage(t, img, "test_inverted_"+name+".png")
+		})
+	}
+}
+
+func TestInvertedBackgroundPlate(t *testing.T) {
+	qr, err := New("https://meet.google.com/abc-defg-hij", High)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	config := DefaultStyleConfig()
+	config.ModuleSize = 10
+	config.QuietZone = 40
+	WithInverted()(&config)
+	WithBackgroundImage(createTestPhoto(400, 400), BackgroundFill)(&config)
+	WithBackgroundPlate(PlateQuietZone, nil)(&config) // Dark plate for inverted symbols
+
+	img, err := qr.ToImage(config)
+	if err != nil {
+		t.Fatalf("Failed to generate image: %v", err)
+	}
+	if !isDark(img, 5, 5) {
+		t.Error("Expected a dark plate over the background image")
+	}
+}
+
+func TestVerifyQuietZone(t *testing.T) {
+	qr, err := New("HELLO WORLD", Medium)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	// A normal rendering has a light quiet zone, which an inverted symbol must not have
+	config := StyleConfig{ModuleSize: 10, QuietZone: 40}
+	img, err := qr.ToImage(config)
+	if err != nil {
+		t.Fatalf("Failed to generate image: %v", err)
+	}
+
+	inverted := StyleConfig{ForegroundColor: color.White, BackgroundColor: color.Black}
+	err = qr.verifyQuietZone(img, inverted, 10, 40)
+	if err == nil || !strings.Contains(err.Error(), "quiet zone") {
+		t.Errorf("Expected quiet zone error, got %v", err)
+	}
+
+	normal := StyleConfig{ForegroundColor: color.Black, BackgroundColor: color.White}
+	if err := qr.verifyQuietZone(img, normal, 10, 40); err != nil {
+		t.Errorf("Unexpected error for a normal quiet zone: %v", err)
+	}
+}
@@ -1,5 +1,55 @@
 package myqrcode
 
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// LogoShape selects the outline a logo is clipped to
+type LogoShape int
+
+const (
+	LogoSquare  LogoShape = iota // Rectangle, the logo is not clipped
+	LogoCircle                   // Circle inscribed in the logo area
+	LogoRounded                  // Rectangle with rounded corners
+)
+
+// LogoStyle controls how a logo sits in the cleared area: the clip shape,
+// a plate behind it that keeps modules from showing through transparent
+// pixels, and an optional border ring.
+type LogoStyle struct {
+	Shape        LogoShape
+	CornerRadius float64 // LogoRounded: radius as a share of half the shorter side (0: 0.3)
+
+	Padding    int         // Space in pixels between the plate edge and the logo
+	PlateColor color.Color // Defaults to BackgroundColor
+
+	BorderWidth int         // Ring width in pixels along the plate edge; 0 for none
+	BorderColor color.Color // Defaults to ForegroundColor
+}
+
+// LogoMode selects how a logo shares its area with the modules
+type LogoMode int
+
+const (
+	LogoExcavate LogoMode = iota // Modules under the logo are cleared
+	LogoBlend                    // Modules are kept and the logo is blended over them
+)
+
+// logoSpec bundles what drawing and measuring a logo needs
+type logoSpec struct {
+	image   image.Image
+	style   *LogoStyle
+	mode    LogoMode
+	opacity float64 // 0 or 1: opaque
+	margin  float64 // Excavation margin in modules
+}
+
 type LogoPlacement struct {
 	X, Y   int
 	Width  int
@@ -114,55 +164,307 @@ func countCriticalOverlap(placement LogoPlacement, size, version int) int {
 	return count
 }
 
-func calculateLogoErrorCorrection(placement LogoPlacement, version int, level ErrorCorrectionLevel) float64 {
-	logoArea := placement.Width * placement.Height
+// clearLogoArea sets the excavated modules to light. It must run after
+// data placement: reserving the area beforehand would shift every following
+// codeword away from where scanners expect it.
+func clearLogoArea(matrix *Matrix, excavation [][]bool) {
+	for y := range excavation {
+		for x, covered := range excavation[y] {
+			if covered && x < matrix.Size && y < matrix.Size {
+				matrix.Set(x, y, false)
+			}
+		}
+	}
+}
+
+// logoFootprintScale is the resolution, in pixels per module, at which the
+// logo's footprint is measured
+const logoFootprintScale = 8
+
+// logoFootprint returns the modules that count against the error budget:
+// the excavated ones, or for a blended logo the ones it may flip
+func logoFootprint(spec logoSpec, placement LogoPlacement, size int) [][]bool {
+	if spec.mode == LogoBlend {
+		return logoBlendRisk(spec, placement, size)
+	}
+	return logoExcavation(spec, placement, size)
+}
+
+// logoExcavation returns the modules the logo covers, as a size x size mask.
+// The logo is drawn the way ToImage draws it, so transparent pixels and clip
+// shapes leave their modules in place. The silhouette is grown by the
+// spec's margin to keep a light gap between the logo and the remaining modules.
+func logoExcavation(spec logoSpec, placement LogoPlacement, size int) [][]bool {
+	margin := spec.margin
+	if margin == 0 {
+		margin = 0.5
+	}
+	margin = max(0, margin)
+
+	// Render the logo on its own; the alpha channel is its footprint
+	scale := logoFootprintScale
+	footprint := image.NewRGBA(image.Rect(0, 0, placement.Width*scale, placement.Height*scale))
+	config := StyleConfig{BackgroundColor: color.White, ForegroundColor: color.Black}
+	drawLogo(footprint, spec, LogoPlacement{Width: placement.Width, Height: placement.Height}, scale, 0, config)
+
+	reach := margin * float64(scale)
+	pad := int(math.Ceil(reach))
 
-	// Get total data codewords for the current version and error correction level
-	versionInfo := getVersionInfo(version)
-	// Calculate total data codewords by summing all block groups for this error correction level
-	totalCodewords := 0
-	for _, blockGroup := range versionInfo.ECBlockInfo[level] {
-		totalCodewords += blockGroup.NumBlocks * blockGroup.DataCodewords
+	excavation := make([][]bool, size)
+	for y := range excavation {
+		excavation[y] = make([]bool, size)
 	}
-	totalDataBits := totalCodewords * 8
 
-	// Calculate the number of bits obscured by the logo
-	// This is an approximation, as it doesn't account for non-data areas covered by the logo
-	obscuredBits := logoArea
+	for my := 0; my < placement.Height; my++ {
+		for mx := 0; mx < placement.Width; mx++ {
+			x, y := placement.X+mx, placement.Y+my
+			if x < 0 || y < 0 || x >= size || y >= size {
+				continue
+			}
 
-	// Calculate the percentage of data that will be obscured
-	obscuredPercentage := float64(obscuredBits) / float64(totalDataBits)
+			// Look for a covered pixel within reach of the module's square
+			module := image.Rect(mx*scale, my*scale, (mx+1)*scale, (my+1)*scale)
+			search := image.Rect(module.Min.X-pad, module.Min.Y-pad, module.Max.X+pad, module.Max.Y+pad).Intersect(footprint.Bounds())
+			excavation[y][x] = coveredNear(footprint, search, module, reach)
+		}
+	}
 
-	// Add a safety margin
-	requiredCorrection := obscuredPercentage * 1.2
+	return excavation
+}
 
-	return requiredCorrection
+// coveredNear reports whether a pixel in search with noticeable alpha lies
+// within reach pixels of module
+func coveredNear(footprint *image.RGBA, search, module image.Rectangle, reach float64) bool {
+	for py := search.Min.Y; py < search.Max.Y; py++ {
+		for px := search.Min.X; px < search.Max.X; px++ {
+			if footprint.RGBAAt(px, py).A < 32 {
+				continue
+			}
+			// Distance from the pixel's center to the module square
+			dx := max(0, float64(module.Min.X)-(float64(px)+0.5), float64(px)+0.5-float64(module.Max.X))
+			dy := max(0, float64(module.Min.Y)-(float64(py)+0.5), float64(py)+0.5-float64(module.Max.Y))
+			if dx*dx+dy*dy <= reach*reach {
+				return true
+			}
+		}
+	}
+	return false
 }
 
-func adjustErrorCorrectionForLogo(level ErrorCorrectionLevel, placement LogoPlacement, version int) ErrorCorrectionLevel {
-	requiredCorrection := calculateLogoErrorCorrection(placement, version, level)
+// logoBlendRisk returns the modules a blended logo may flip, as a size x size
+// mask. The logo is blended over an all-dark and an all-light symbol; a
+// module is at risk when either reads as the opposite color. Which modules
+// are dark is only known after masking, so both are assumed.
+func logoBlendRisk(spec logoSpec, placement LogoPlacement, size int) [][]bool {
+	scale := logoFootprintScale
+	bounds := image.Rect(0, 0, placement.Width*scale, placement.Height*scale)
+	config := StyleConfig{BackgroundColor: color.White, ForegroundColor: color.Black}
+	threshold := contrastMidpoint(0, 1)
 
-	// Map correction percentages to levels
-	// Low: ~7%, Medium: ~15%, Quartile: ~25%, High: ~30%
-	if requiredCorrection > 0.25 {
-		return High
-	} else if requiredCorrection > 0.15 {
-		return Quartile
-	} else if requiredCorrection > 0.07 {
-		return Medium
+	var blended [2]*image.RGBA
+	for i, c := range []color.Color{color.Black, color.White} {
+		blended[i] = image.NewRGBA(bounds)
+		draw.Draw(blended[i], bounds, &image.Uniform{c}, image.Point{}, draw.Src)
+		drawLogo(blended[i], spec, LogoPlacement{Width: placement.Width, Height: placement.Height}, scale, 0, config)
 	}
 
-	return level // Keep original if no adjustment needed
+	risk := make([][]bool, size)
+	for y := range risk {
+		risk[y] = make([]bool, size)
+	}
+	for my := 0; my < placement.Height; my++ {
+		for mx := 0; mx < placement.Width; mx++ {
+			x, y := placement.X+mx, placement.Y+my
+			if x < 0 || y < 0 || x >= size || y >= size {
+				continue
+			}
+			dark := sampleLuminance(blended[0], mx*scale, my*scale, scale)
+			light := sampleLuminance(blended[1], mx*scale, my*scale, scale)
+			risk[y][x] = dark >= threshold || light < threshold
+		}
+	}
+	return risk
 }
 
-func reserveLogoArea(matrix *Matrix, placement LogoPlacement) {
-	for y := placement.Y; y < placement.Y+placement.Height; y++ {
-		for x := placement.X; x < placement.X+placement.Width; x++ {
-			if x >= 0 && x < matrix.Size && y >= 0 && y < matrix.Size {
-				matrix.SetReserved(x, y)
-				// Set to false (white) to create space for logo
-				matrix.Set(x, y, false)
+// contrastMidpoint returns the luminance with equal contrast ratios to
+// luminances a and b, the point where a module stops reading as either
+func contrastMidpoint(a, b float64) float64 {
+	return math.Sqrt((a+0.05)*(b+0.05)) - 0.05
+}
+
+// verifyLogoBlend samples the modules under blended overlays and fails
+// when those that now read as the wrong color, together with the cleared
+// ones, exceed what a block can correct. Each module is judged against
+// its own dark color, the role color or the fill, composited over white
+// the way the samples are
+func (qr *QRCode) verifyLogoBlend(img image.Image, config StyleConfig, placements []LogoPlacement, moduleSize, quietZone int) error {
+	bg := relativeLuminance(opaque(config.BackgroundColor))
+	var fill image.Image
+	if config.Fill != nil {
+		symbolSize := qr.Size * moduleSize
+		fill = config.Fill.Image(image.Rect(quietZone, quietZone, quietZone+symbolSize, quietZone+symbolSize))
+	}
+	roles := qr.ModuleRoles()
+	darkLuminance := func(x, y int) float64 {
+		c := config.colorFor(roles[y][x])
+		if fill != nil && sameRGBA(c, config.ForegroundColor) {
+			return sampleLuminance(fill, quietZone+x*moduleSize, quietZone+y*moduleSize, moduleSize)
+		}
+		return relativeLuminance(opaque(c))
+	}
+
+	damaged := make([][]bool, qr.Size)
+	for y := range damaged {
+		damaged[y] = make([]bool, qr.Size)
+		if y < len(qr.cleared) {
+			copy(damaged[y], qr.cleared[y])
+		}
+	}
+
+	flips := 0
+	for _, placement := range placements {
+		for y := max(0, placement.Y); y < min(qr.Size, placement.Y+placement.Height); y++ {
+			for x := max(0, placement.X); x < min(qr.Size, placement.X+placement.Width); x++ {
+				lum := sampleLuminance(img, quietZone+x*moduleSize, quietZone+y*moduleSize, moduleSize)
+				fg := darkLuminance(x, y)
+				readsDark := (lum < contrastMidpoint(fg, bg)) == (fg < bg)
+				if readsDark != qr.Matrix[y][x] && !damaged[y][x] {
+					damaged[y][x] = true
+					flips++
+				}
 			}
 		}
 	}
+
+	if !withinBudget(logoDamage(qr.Version, qr.ErrorCorrection, damaged), 0) {
+		return fmt.Errorf("blended logo flips %d modules, more than error correction can recover; lower the logo opacity", flips)
+	}
+	return nil
+}
+
+// countModules returns the number of set modules in a mask
+func countModules(mask [][]bool) int {
+	count := 0
+	for _, row := range mask {
+		for _, set := range row {
+			if set {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// logoContour builds the style's outline for a w x h area, shrunk by inset
+// on every side
+func (s *LogoStyle) logoContour(w, h, inset float32) *contour {
+	w, h = w-2*inset, h-2*inset
+	if w <= 0 || h <= 0 {
+		return nil
+	}
+
+	switch s.Shape {
+	case LogoCircle:
+		return circle(inset+w/2, inset+h/2, min(w, h)/2)
+	case LogoRounded:
+		ratio := float32(s.CornerRadius)
+		if ratio <= 0 || ratio > 1 {
+			ratio = 0.3
+		}
+		// The radius shrinks with the inset so nested outlines stay parallel
+		r := max(0, ratio*(min(w, h)+2*inset)/2-inset)
+		return roundedRect(inset, inset, w, h, [4]float32{r, r, r, r})
+	default:
+		return roundedRect(inset, inset, w, h, [4]float32{})
+	}
+}
+
+// drawLogo draws the logo into the placement area. A partly opaque logo is
+// drawn on a layer first and faded as a whole.
+func drawLogo(img *image.RGBA, spec logoSpec, placement LogoPlacement, moduleSize, quietZone int, config StyleConfig) {
+	logoX := quietZone + placement.X*moduleSize
+	logoY := quietZone + placement.Y*moduleSize
+	area := image.Rect(logoX, logoY, logoX+placement.Width*moduleSize, logoY+placement.Height*moduleSize)
+
+	if spec.opacity <= 0 || spec.opacity >= 1 {
+		spec.paint(img, area, config)
+		return
+	}
+
+	layer := image.NewRGBA(area)
+	spec.paint(layer, area, config)
+	fade := image.NewUniform(color.Alpha{uint8(spec.opacity*255 + 0.5)})
+	draw.DrawMask(img, area, layer, area.Min, fade, image.Point{}, draw.Over)
+}
+
+// paint draws the logo into area. Without a style the logo is scaled to
+// fill the area; with one it gets a plate, an optional border and is clipped
+// to the style's shape. Blended logos get no plate, so the modules stay visible.
+func (spec logoSpec) paint(img *image.RGBA, area image.Rectangle, config StyleConfig) {
+	logo, style := spec.image, spec.style
+	vectorLogo, _ := logo.(*SVGLogo)
+	if style == nil {
+		if vectorLogo != nil {
+			vectorLogo.draw(img, area)
+			return
+		}
+		// Use bilinear scaling for better quality
+		xdraw.BiLinear.Scale(img, area, logo, logo.Bounds(), xdraw.Over, nil)
+		return
+	}
+
+	w, h := float32(area.Dx()), float32(area.Dy())
+
+	plateColor := style.PlateColor
+	if plateColor == nil {
+		plateColor = config.BackgroundColor
+	}
+	if plate := style.logoContour(w, h, 0); plate != nil && spec.mode != LogoBlend {
+		drawContours(img, area, plateColor, config.Quality, plate)
+	}
+	border := float32(max(0, style.BorderWidth))
+	if border > 0 {
+		borderColor := style.BorderColor
+		if borderColor == nil {
+			borderColor = config.ForegroundColor
+		}
+		if outer := style.logoContour(w, h, 0); outer != nil {
+			var holes []*contour
+			if inner := style.logoContour(w, h, border); inner != nil {
+				holes = append(holes, inner)
+			}
+			mask := contourMask(area.Dx(), area.Dy(), config.Quality, []*contour{outer}, holes...)
+			draw.DrawMask(img, area, &image.Uniform{borderColor}, image.Point{}, mask, image.Point{}, draw.Over)
+		}
+	}
+
+	inset := border + float32(max(0, style.Padding))
+	clip := style.logoContour(w, h, inset)
+	if clip == nil {
+		return
+	}
+
+	// Fit the logo into the space left inside the border and padding,
+	// keeping its aspect ratio
+	box := area.Inset(int(inset))
+	src := logo.Bounds()
+	if src.Empty() || box.Empty() {
+		return
+	}
+	scale := min(float64(box.Dx())/float64(src.Dx()), float64(box.Dy())/float64(src.Dy()))
+	dw, dh := int(float64(src.Dx())*scale+0.5), int(float64(src.Dy())*scale+0.5)
+	dst := image.Rect(0, 0, dw, dh).Add(box.Min).Add(image.Pt((box.Dx()-dw)/2, (box.Dy()-dh)/2))
+
+	// Scale into a transparent layer first so the clip mask applies to the
+	// logo's own alpha, then composite the layer over the plate. Vector
+	// logos are rasterized at the target size instead of resampled.
+	layer := image.NewRGBA(area)
+	if vectorLogo != nil {
+		vectorLogo.draw(layer, dst)
+	} else {
+		xdraw.CatmullRom.Scale(layer, dst, logo, src, xdraw.Src, nil)
+	}
+
+	mask := contourMask(area.Dx(), area.Dy(), config.Quality, []*contour{clip})
+	draw.DrawMask(img, area, layer, area.Min, mask, image.Point{}, draw.Over)
 }
@@ -0,0 +1,180 @@
+package myqrcode
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+	"testing"
+)
+
+func TestLogoBlend(t *testing.T) {
+	data := "https://meet.google.com/abc-defg-hij"
+
+	plain, err := New(data, High)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := plain.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	qr, err := New(data, High)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	qr.SetLogo(createRoundLogo(200), 30)
+	qr.SetLogoBlend(0.35)
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	// The modules under a blended logo are encoded as usual
+	if qr.Version != plain.Version {
+		t.Fatalf("Expected version %d, got %d", plain.Version, qr.Version)
+	}
+	for y := range qr.Matrix {
+		for x := range qr.Matrix[y] {
+			if qr.Matrix[y][x] != plain.Matrix[y][x] {
+				t.Fatalf("Module (%d, %d) differs from the code without a logo", x, y)
+			}
+		}
+	}
+
+	img, err := qr.ToImage(StyleConfig{ModuleSize: 10, QuietZone: 40})
+	if err != nil {
+		t.Fatalf("Failed to generate image: %v", err)
+	}
+
+	// Dark modules show through the logo
+	placement := optimizeLogoPlacement(&Matrix{Size: qr.Size}, qr.LogoSize, qr.Version)
+	cx, cy := placement.X+placement.Width/2, placement.Y+placement.Height/2
+	for y := cy - 1; y <= cy+1; y++ {
+		for x := cx - 1; x <= cx+1; x++ {
+			lum := sampleLuminance(img, 40+x*10, 40+y*10, 10)
+			if qr.Matrix[y][x] && lum > 0.2 {
+				t.Errorf("Expected dark module (%d, %d) to show through the logo, luminance %.2f", x, y, lum)
+			}
+		}
+	}
+
+	saveTestImage(t, img, "test_logo_blend.png")
+}
+
+func TestLogoBlendRisk(t *testing.T) {
+	placement := LogoPlacement{X: 10, Y: 10, Width: 11, Height: 11}
+	logo := image.NewRGBA(image.Rect(0, 0, 50, 50))
+	draw.Draw(logo, logo.Bounds(), image.NewUniform(color.RGBA{40, 60, 160, 255}), image.Point{}, draw.Src)
+
+	faint := logoBlendRisk(logoSpec{image: logo, mode: LogoBlend, opacity: 0.2}, placement, 33)
+	if n := countModules(faint); n != 0 {
+		t.Errorf("Expected a faint logo to flip no modules, got %d", n)
+	}
+
+	solid := logoBlendRisk(logoSpec{image: logo, mode: LogoBlend}, placement, 33)
+	if n := countModules(solid); n != 11*11 {
+		t.Errorf("Expected an opaque logo to put all %d modules at risk, got %d", 11*11, n)
+	}
+}
+
+func TestVerifyLogoBlend(t *testing.T) {
+	qr, err := New("HELLO WORLD", Low)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	config := StyleConfig{ModuleSize: 10, QuietZone: 40, ForegroundColor: color.Black, BackgroundColor: color.White}
+	img, err := qr.ToImage(config)
+	if err != nil {
+		t.Fatalf("Failed to generate image: %v", err)
+	}
+	everything := LogoPlacement{Width: qr.Size, Height: qr.Size}
+	if err := qr.verifyLogoBlend(img, config, []LogoPlacement{everything}, 10, 40); err != nil {
+		t.Errorf("Unexpected error for an untouched symbol: %v", err)
+	}
+
+	// Wash out the middle of the symbol
+	rgba := img.(*image.RGBA)
+	draw.Draw(rgba, image.Rect(90, 90, 220, 220), image.White, image.Point{}, draw.Src)
+	err = qr.verifyLogoBlend(rgba, config, []LogoPlacement{everything}, 10, 40)
+	if err == nil || !strings.Contains(err.Error(), "flips") {
+		t.Errorf("Expected a flipped modules error, got %v", err)
+	}
+}
+
+func TestVerifyLogoBlendModuleColors(t *testing.T) {
+	gray := color.RGBA{128, 128, 128, 255}
+	configs := map[string]StyleConfig{
+		"transparent background": {ForegroundColor: color.Black, BackgroundColor: color.Transparent},
+		"role colors":            {ForegroundColor: color.Black, BackgroundColor: color.White, RoleColors: map[ModuleRole]color.Color{RoleData: gray}},
+		"fill":                   {ForegroundColor: color.Black, BackgroundColor: color.White, Fill: NewLinearGradient(0, ColorStop{0, color.Black}, ColorStop{1, gray})},
+	}
+
+	for name, config := range configs {
+		qr, err := New("HELLO WORLD", Low)
+		if err != nil {
+			t.Fatalf("Failed to create QR code: %v", err)
+		}
+		if err := qr.Encode(); err != nil {
+			t.Fatalf("Failed to encode QR code: %v", err)
+		}
+
+		config.ModuleSize, config.QuietZone = 10, 40
+		img, err := qr.ToImage(config)
+		if err != nil {
+			t.Fatalf("%s: failed to generate image: %v", name, err)
+		}
+		everything := LogoPlacement{Width: qr.Size, Height: qr.Size}
+		if err := qr.verifyLogoBlend(img, config, []LogoPlacement{everything}, 10, 40); err != nil {
+			t.Errorf("%s: unexpected error for an untouched symbol: %v", name, err)
+		}
+	}
+}
+
+func TestLogoBlendOpacity(t *testing.T) {
+	for _, opacity := range []float64{0, -0.5, 1, 1.5} {
+		qr, err := New("https://meet.google.com/abc-defg-hij", High)
+		if err != nil {
+			t.Fatalf("Failed to create QR code: %v", err)
+		}
+		qr.SetLogo(createRoundLogo(200), 20)
+		qr.SetLogoBlend(opacity)
+		if err := qr.Encode(); err == nil || !strings.Contains(err.Error(), "opacity") {
+			t.Errorf("Expected an opacity error for %v, got %v", opacity, err)
+		}
+	}
+}
+
+func TestLogoBlendSVG(t *testing.T) {
+	qr, err := New("https://meet.google.com/abc-defg-hij", Low)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+
+	// Half a near-black logo keeps black and white modules apart, so Encode
+	// budgets nothing for it
+	logo := image.NewRGBA(image.Rect(0, 0, 50, 50))
+	draw.Draw(logo, logo.Bounds(), image.NewUniform(color.RGBA{20, 20, 20, 255}), image.Point{}, draw.Src)
+	qr.SetLogo(logo, 40)
+	qr.SetLogoBlend(0.5)
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+	config := StyleConfig{ModuleSize: 10, QuietZone: 40}
+	if _, err := qr.ToSVG(config); err != nil {
+		t.Errorf("Unexpected error for black and white modules: %v", err)
+	}
+
+	// On a gray background it darkens the light modules past the midpoint
+	config.ForegroundColor = color.RGBA{60, 60, 60, 255}
+	config.BackgroundColor = color.RGBA{170, 170, 170, 255}
+	if _, err := qr.ToImage(config); err == nil {
+		t.Fatal("Expected ToImage to reject the blend on gray")
+	}
+	if _, err := qr.ToSVG(config); err == nil || !strings.Contains(err.Error(), "flips") {
+		t.Errorf("Expected ToSVG to reject the blend on gray, got %v", err)
+	}
+}
@@ -0,0 +1,223 @@
+package myqrcode
+
+import (
+	"errors"
+	"fmt"
+	"image"
+)
+
+// LogoFit describes the largest logo FitLogo found room for
+type LogoFit struct {
+	Version         int
+	ErrorCorrection ErrorCorrectionLevel
+	LogoSize        int // Percentage of the symbol width, as passed to SetLogo
+	Modules         int // Modules counted against the budget, overlays included
+}
+
+// maxLogoPercent bounds the FitLogo search, well past what High error
+// correction can recover
+const maxLogoPercent = 60
+
+// fitLogoVersions is how many versions above the minimum FitLogo tries.
+// Bigger symbols rarely fit a larger share and shrink every module.
+const fitLogoVersions = 4
+
+// blockDamage is how many codewords of one Reed-Solomon block a logo covers
+type blockDamage struct {
+	damaged  int // Codewords with at least one covered module
+	capacity int // Codeword errors the block can correct
+}
+
+// codewordBlocks returns, for each codeword in placement order, the index of
+// the block it belongs to. It mirrors the interleaving in addErrorCorrection:
+// data codewords column by column across blocks, then EC codewords likewise.
+func codewordBlocks(version int, level ErrorCorrectionLevel) []int {
+	var dataLens, ecLens []int
+	for _, group := range getVersionInfo(version).ECBlockInfo[level] {
+		for i := 0; i < group.NumBlocks; i++ {
+			dataLens = append(dataLens, group.DataCodewords)
+			ecLens = append(ecLens, group.TotalCodewords-group.DataCodewords)
+		}
+	}
+
+	var blocks []int
+	for _, lens := range [][]int{dataLens, ecLens} {
+		longest := 0
+		for _, n := range lens {
+			longest = max(longest, n)
+		}
+		for i := 0; i < longest; i++ {
+			for b, n := range lens {
+				if i < n {
+					blocks = append(blocks, b)
+				}
+			}
+		}
+	}
+	return blocks
+}
+
+// misdecodeProtection returns the EC codewords the smallest symbols set aside
+// to detect misdecodes rather than to correct errors (ISO/IEC 18004, table 9)
+func misdecodeProtection(version int, level ErrorCorrectionLevel) int {
+	switch {
+	case version == 1 && level == Low:
+		return 3
+	case version == 1 && level == Medium, version == 2 && level == Low:
+		return 2
+	case version == 1, version == 3 && level == Low:
+		return 1
+	}
+	return 0
+}
+
+// logoDamage maps every excavated module back to its codeword and block via
+// the data placement order, and counts the damaged codewords per block.
+// Every covered codeword counts as an error: whether a cleared module
+// differs from the data underneath depends on the mask, chosen later.
+func logoDamage(version int, level ErrorCorrectionLevel, excavation [][]bool) []blockDamage {
+	blocks := codewordBlocks(version, level)
+
+	damage := make([]blockDamage, 0, len(blocks))
+	protection := misdecodeProtection(version, level)
+	for _, group := range getVersionInfo(version).ECBlockInfo[level] {
+		ec := group.TotalCodewords - group.DataCodewords
+		for i := 0; i < group.NumBlocks; i++ {
+			damage = append(damage, blockDamage{capacity: (ec - protection) / 2})
+		}
+	}
+
+	damaged := make([]bool, len(blocks))
+	for i, pos := range dataModulePositions(newSymbolMatrix(version)) {
+		codeword := i / 8
+		if codeword >= len(blocks) {
+			break // Remainder bits carry no data
+		}
+		x, y := pos[0], pos[1]
+		if y < len(excavation) && x < len(excavation[y]) && excavation[y][x] && !damaged[codeword] {
+			damaged[codeword] = true
+			damage[blocks[codeword]].damaged++
+		}
+	}
+
+	return damage
+}
+
+// withinBudget reports whether every block can correct the codewords the
+// logo covers and still has spare codewords left
+func withinBudget(damage []blockDamage, spare int) bool {
+	for _, d := range damage {
+		if d.damaged+spare > d.capacity {
+			return false
+		}
+	}
+	return true
+}
+
+// planLogo picks the smallest version, and within it the lowest error
+// correction level no lower than the requested one, where the data fits
+// and every block can correct the codewords the overlays cover with
+// qr.LogoSpare codewords to spare. With a fixed version only the level is
+// raised. It returns the modules to clear for the chosen version.
+func (qr *QRCode) planLogo(fixedVersion bool) (int, ErrorCorrectionLevel, [][]bool, error) {
+	overlays := qr.overlays()
+	for version := qr.Version; version <= 40; version++ {
+		if footprint, cleared, ok := overlayFootprints(overlays, version); ok {
+			for level := qr.ErrorCorrection; level <= High; level++ {
+				if len(qr.Data) > DataCapacity(version, qr.Mode, level) {
+					continue
+				}
+				if withinBudget(logoDamage(version, level, footprint), qr.LogoSpare) {
+					return version, level, cleared, nil
+				}
+			}
+		}
+
+		if fixedVersion {
+			return 0, 0, nil, fmt.Errorf("logos cover more codewords than version %d can correct, or a finder, timing or format pattern", version)
+		}
+	}
+
+	return 0, 0, nil, errors.New("no version fits the logos: they cover too many codewords, or a finder, timing or format pattern")
+}
+
+// FitLogo finds the largest logo size that leaves spare codewords unused in
+// every Reed-Solomon block, counting the damage of added overlays too. The
+// logo keeps its anchor and style. It tries the error correction levels from the
+// requested one up to High, and versions from the smallest that holds the
+// data (or only qr.Version when set). Ties go to the smaller version and
+// lower level. The logo, size, version and level are set on qr, so Encode
+// reproduces the fit.
+func (qr *QRCode) FitLogo(logo image.Image, spare int) (LogoFit, error) {
+	if logo == nil {
+		return LogoFit{}, errors.New("logo cannot be nil")
+	}
+	if qr.Mode == Auto {
+		qr.Mode = detectMode(qr.Data)
+	} else if err := validateMode(qr.Data, qr.Mode); err != nil {
+		return LogoFit{}, err
+	}
+	spare = max(0, spare)
+	for _, o := range append([]Overlay{qr.logoOverlay(logo, 1)}, qr.Overlays...) {
+		if err := o.validate(); err != nil {
+			return LogoFit{}, err
+		}
+	}
+
+	first, last := qr.Version, qr.Version
+	if qr.Version == 0 {
+		version, err := MinimumVersion(len(qr.Data), qr.Mode, qr.ErrorCorrection)
+		if err != nil {
+			return LogoFit{}, err
+		}
+		first, last = version, min(40, version+fitLogoVersions)
+	}
+
+	var best LogoFit
+	for version := first; version <= last; version++ {
+		// Every level tries the same sizes, so keep the footprints; nil
+		// marks a size that covers a function pattern
+		footprints := make(map[int][][]bool)
+		footprint := func(percent int) [][]bool {
+			if mask, ok := footprints[percent]; ok {
+				return mask
+			}
+			overlays := append([]Overlay{qr.logoOverlay(logo, percent)}, qr.Overlays...)
+			mask, _, ok := overlayFootprints(overlays, version)
+			if !ok {
+				mask = nil
+			}
+			footprints[percent] = mask
+			return mask
+		}
+
+		for level := qr.ErrorCorrection; level <= High; level++ {
+			if len(qr.Data) > DataCapacity(version, qr.Mode, level) {
+				continue
+			}
+
+			// Damage grows with the logo, so binary search the largest size
+			lo, hi := 0, maxLogoPercent
+			for lo < hi {
+				mid := (lo + hi + 1) / 2
+				if mask := footprint(mid); mask != nil && withinBudget(logoDamage(version, level, mask), spare) {
+					lo = mid
+				} else {
+					hi = mid - 1
+				}
+			}
+
+			if lo > best.LogoSize {
+				best = LogoFit{Version: version, ErrorCorrection: level, LogoSize: lo, Modules: countModules(footprint(lo))}
+			}
+		}
+	}
+
+	if best.LogoSize == 0 {
+		return LogoFit{}, fmt.Errorf("no logo fits with %d spare codewords per block", spare)
+	}
+
+	qr.Logo, qr.LogoSize, qr.LogoSpare = logo, best.LogoSize, spare
+	qr.Version, qr.ErrorCorrection = best.Version, best.ErrorCorrection
+	return best, nil
+}
@@ -0,0 +1,202 @@
+package myqrcode
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCodewordBlocks(t *testing.T) {
+	// Version 5-Q has two groups of two blocks with 15 and 16 data codewords
+	version, level := 5, Quartile
+	groups := getVersionInfo(version).ECBlockInfo[level]
+
+	// Fill each block's data codewords with its block number; after
+	// interleaving, the data part must read back as the block mapping
+	var data []int
+	block := 0
+	for _, group := range groups {
+		for i := 0; i < group.NumBlocks; i++ {
+			for j := 0; j < group.DataCodewords; j++ {
+				for bit := 7; bit >= 0; bit-- {
+					data = append(data, (block>>bit)&1)
+				}
+			}
+			block++
+		}
+	}
+
+	codewords := addErrorCorrection(data, version, level)
+	blocks := codewordBlocks(version, level)
+	if len(blocks) != len(codewords) {
+		t.Fatalf("Expected %d codewords, got %d", len(codewords), len(blocks))
+	}
+	for i := 0; i < len(data)/8; i++ {
+		if int(codewords[i]) != blocks[i] {
+			t.Fatalf("Codeword %d: expected block %d, got %d", i, codewords[i], blocks[i])
+		}
+	}
+
+	// Every block owns all its codewords
+	counts := make(map[int]int)
+	for _, b := range blocks {
+		counts[b]++
+	}
+	block = 0
+	for _, group := range groups {
+		for i := 0; i < group.NumBlocks; i++ {
+			if counts[block] != group.TotalCodewords {
+				t.Errorf("Block %d: expected %d codewords, got %d", block, group.TotalCodewords, counts[block])
+			}
+			block++
+		}
+	}
+}
+
+func TestLogoDamage(t *testing.T) {
+	version, level := 7, Medium
+	size := getVersionInfo(version).Size
+
+	excavation := make([][]bool, size)
+	for y := range excavation {
+		excavation[y] = make([]bool, size)
+	}
+
+	// A single module damages exactly one codeword
+	pos := dataModulePositions(newSymbolMatrix(version))[100]
+	excavation[pos[1]][pos[0]] = true
+	total := 0
+	for _, d := range logoDamage(version, level, excavation) {
+		total += d.damaged
+	}
+	if total != 1 {
+		t.Errorf("Expected one damaged codeword, got %d", total)
+	}
+
+	// Covering everything damages every codeword of every block
+	for y := range excavation {
+		for x := range excavation[y] {
+			excavation[y][x] = true
+		}
+	}
+	damage := logoDamage(version, level, excavation)
+	for i, d := range damage {
+		if d.damaged != 49 || d.capacity != 9 {
+			t.Errorf("Block %d: expected 49 damaged codewords and capacity 9, got %+v", i, d)
+		}
+	}
+	if withinBudget(damage, 0) {
+		t.Error("Expected a fully covered symbol to exceed the budget")
+	}
+
+	if p := misdecodeProtection(1, Low); p != 3 {
+		t.Errorf("Expected 3 misdecode protection codewords for 1-L, got %d", p)
+	}
+}
+
+func TestLogoPlanning(t *testing.T) {
+	data := "https://meet.google.com/abc-defg-hij"
+
+	// A small logo fits the requested level
+	qr, err := New(data, Medium)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	qr.SetLogo(createRoundLogo(200), 10)
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+	if qr.ErrorCorrection != Medium {
+		t.Errorf("Expected Medium to suffice for a small logo, got %d", qr.ErrorCorrection)
+	}
+
+	// A large logo raises the level and, once the data no longer fits, the version
+	qr, err = New(data, Medium)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	qr.SetLogo(createRoundLogo(200), 30)
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+	minimum, _ := MinimumVersion(len(data), Byte, Medium)
+	if qr.ErrorCorrection == Medium && qr.Version == minimum {
+		t.Error("Expected a large logo to raise the level or version")
+	}
+	if len(data) > DataCapacity(qr.Version, qr.Mode, qr.ErrorCorrection) {
+		t.Errorf("Data does not fit version %d at level %d", qr.Version, qr.ErrorCorrection)
+	}
+
+	img, err := qr.ToImage(StyleConfig{ModuleSize: 10, QuietZone: 40})
+	if err != nil {
+		t.Fatalf("Failed to generate image: %v", err)
+	}
+	saveTestImage(t, img, "test_logo_budget_30.png")
+
+	// A fixed version cannot grow
+	qr, err = New(data, High)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	qr.Version = 3
+	qr.SetLogo(createTestLogo(100), 60)
+	err = qr.Encode()
+	if err == nil || !strings.Contains(err.Error(), "version 3") {
+		t.Errorf("Expected a budget error for a fixed version, got %v", err)
+	}
+}
+
+func TestFitLogo(t *testing.T) {
+	data := "https://meet.google.com/abc-defg-hij"
+
+	qr, err := New(data, Medium)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	fit, err := qr.FitLogo(createRoundLogo(200), 1)
+	if err != nil {
+		t.Fatalf("Failed to fit logo: %v", err)
+	}
+	if fit.LogoSize <= 0 || fit.Modules <= 0 || fit.ErrorCorrection < Medium {
+		t.Fatalf("Unexpected fit: %+v", fit)
+	}
+	t.Logf("Fit: version %d, level %d, %d%% logo, %d modules", fit.Version, fit.ErrorCorrection, fit.LogoSize, fit.Modules)
+
+	// A linear scan over every size finds the same largest logo
+	largest := 0
+	for percent := 1; percent <= maxLogoPercent; percent++ {
+		mask, _, ok := overlayFootprints([]Overlay{{Image: createRoundLogo(200), Size: percent}}, fit.Version)
+		if ok && withinBudget(logoDamage(fit.Version, fit.ErrorCorrection, mask), 1) {
+			largest = percent
+		}
+	}
+	if largest != fit.LogoSize {
+		t.Errorf("Expected the largest logo within the budget to be %d%%, FitLogo found %d%%", largest, fit.LogoSize)
+	}
+
+	// Encode reproduces the fit
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+	if qr.Version != fit.Version || qr.ErrorCorrection != fit.ErrorCorrection || qr.LogoSize != fit.LogoSize {
+		t.Errorf("Expected Encode to keep version %d level %d, got version %d level %d", fit.Version, fit.ErrorCorrection, qr.Version, qr.ErrorCorrection)
+	}
+
+	img, err := qr.ToImage(StyleConfig{ModuleSize: 10, QuietZone: 40})
+	if err != nil {
+		t.Fatalf("Failed to generate image: %v", err)
+	}
+	saveTestImage(t, img, "test_logo_fit.png")
+
+	// More spare codewords mean a smaller logo
+	qr, err = New(data, Medium)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	cautious, err := qr.FitLogo(createRoundLogo(200), 4)
+	if err != nil {
+		t.Fatalf("Failed to fit logo: %v", err)
+	}
+	if cautious.LogoSize >= fit.LogoSize {
+		t.Errorf("Expected a smaller logo with more spare codewords, got %d%% and %d%%", cautious.LogoSize, fit.LogoSize)
+	}
+}
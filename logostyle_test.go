@@ -0,0 +1,206 @@
+package myqrcode
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+// createTestLogo draws an opaque red square on a transparent canvas, so the
+// outer quarter of each side is see-through
+func createTestLogo(size int) *image.NRGBA {
+	logo := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := size / 4; y < size*3/4; y++ {
+		for x := size / 4; x < size*3/4; x++ {
+			logo.SetNRGBA(x, y, color.NRGBA{220, 30, 30, 255})
+		}
+	}
+	return logo
+}
+
+func TestLogoStyles(t *testing.T) {
+	red := color.NRGBA{220, 30, 30, 255}
+	yellow := color.NRGBA{255, 240, 180, 255}
+	blue := color.NRGBA{20, 40, 200, 255}
+
+	// A fully opaque logo shows the clip shape; the partly transparent one
+	// shows the plate through its clear pixels
+	opaque := image.NewNRGBA(image.Rect(0, 0, 80, 80))
+	draw.Draw(opaque, opaque.Bounds(), image.NewUniform(red), image.Point{}, draw.Src)
+
+	tests := []struct {
+		name  string
+		logo  image.Image
+		style LogoStyle
+		check func(t *testing.T, img image.Image, area image.Rectangle)
+	}{
+		{
+			name:  "circle",
+			logo:  opaque,
+			style: LogoStyle{Shape: LogoCircle},
+			check: func(t *testing.T, img image.Image, area image.Rectangle) {
+				if !sameColor(img.At(area.Min.X+2, area.Min.Y+2), color.White) {
+					t.Errorf("Expected the corner outside the circle to stay light, got %v", img.At(area.Min.X+2, area.Min.Y+2))
+				}
+			},
+		},
+		{
+			name:  "rounded_plate_border",
+			logo:  createTestLogo(100),
+			style: LogoStyle{Shape: LogoRounded, Padding: 6, PlateColor: yellow, BorderWidth: 4, BorderColor: blue},
+			check: func(t *testing.T, img image.Image, area image.Rectangle) {
+				mid := area.Min.X + area.Dx()/2
+				if !sameColor(img.At(mid, area.Min.Y+2), blue) {
+					t.Errorf("Expected the border ring at the top edge, got %v", img.At(mid, area.Min.Y+2))
+				}
+				if !sameColor(img.At(mid, area.Min.Y+7), yellow) {
+					t.Errorf("Expected the plate inside the border, got %v", img.At(mid, area.Min.Y+7))
+				}
+				// Transparent logo pixels let the plate through
+				if !sameColor(img.At(mid, area.Min.Y+14), yellow) {
+					t.Errorf("Expected the plate behind transparent logo pixels, got %v", img.At(mid, area.Min.Y+14))
+				}
+			},
+		},
+		{
+			name:  "square_padding",
+			logo:  opaque,
+			style: LogoStyle{Padding: 8},
+			check: func(t *testing.T, img image.Image, area image.Rectangle) {
+				if !sameColor(img.At(area.Min.X+4, area.Min.Y+4), color.White) {
+					t.Errorf("Expected the padding in the background color, got %v", img.At(area.Min.X+4, area.Min.Y+4))
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qr, err := New("https://meet.google.com/abc-defg-hij", High)
+			if err != nil {
+				t.Fatalf("Failed to create QR code: %v", err)
+			}
+			qr.SetLogo(tt.logo, 25)
+			qr.SetLogoStyle(tt.style)
+			if err := qr.Encode(); err != nil {
+				t.Fatalf("Failed to encode QR code: %v", err)
+			}
+
+			config := DefaultStyleConfig()
+			config.ModuleSize = 10
+			config.QuietZone = 40
+
+			img, err := qr.ToImage(config)
+			if err != nil {
+				t.Fatalf("Failed to generate image: %v", err)
+			}
+
+			placement := optimizeLogoPlacement(&Matrix{Size: qr.Size}, qr.LogoSize, qr.Version)
+			area := image.Rect(40+placement.X*10, 40+placement.Y*10, 40+(placement.X+placement.Width)*10, 40+(placement.Y+placement.Height)*10)
+
+			center := img.At(area.Min.X+area.Dx()/2, area.Min.Y+area.Dy()/2)
+			if !sameColor(center, red) {
+				t.Errorf("Expected the logo in the middle of the area, got %v", center)
+			}
+			tt.check(t, img, area)
+
+			saveTestImage(t, img, "test_logo_style_"+tt.name+".png")
+		})
+	}
+}
+
+// sameColor reports whether two colors match within a small tolerance
+func sameColor(a, b color.Color) bool {
+	ca := color.NRGBAModel.Convert(a).(color.NRGBA)
+	cb := color.NRGBAModel.Convert(b).(color.NRGBA)
+	return absDiff(ca.R, cb.R) <= 8 && absDiff(ca.G, cb.G) <= 8 && absDiff(ca.B, cb.B) <= 8 && absDiff(ca.A, cb.A) <= 8
+}
+
+// createRoundLogo draws an opaque disc on a transparent canvas
+func createRoundLogo(size int) *image.NRGBA {
+	logo := image.NewNRGBA(image.Rect(0, 0, size, size))
+	r := float64(size) / 2
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			dx, dy := float64(x)+0.5-r, float64(y)+0.5-r
+			if dx*dx+dy*dy <= r*r {
+				logo.SetNRGBA(x, y, color.NRGBA{30, 90, 200, 255})
+			}
+		}
+	}
+	return logo
+}
+
+func TestLogoExcavation(t *testing.T) {
+	placement := LogoPlacement{X: 10, Y: 10, Width: 11, Height: 11}
+	size := 33
+
+	// An opaque logo fills its area
+	opaque := image.NewGray(image.Rect(0, 0, 50, 50))
+	full := logoExcavation(logoSpec{image: opaque}, placement, size)
+	if n := countModules(full); n != 11*11 {
+		t.Errorf("Expected an opaque logo to cover all %d modules, got %d", 11*11, n)
+	}
+
+	// A round logo keeps the corners of its area
+	round := logoExcavation(logoSpec{image: createRoundLogo(200)}, placement, size)
+	n := countModules(round)
+	if n >= 11*11 || n < 80 {
+		t.Errorf("Expected a round logo to cover fewer modules than its area, got %d", n)
+	}
+	if round[10][10] || round[20][20] {
+		t.Error("Expected the corners of the logo area to keep their modules")
+	}
+	if !round[15][15] || !round[15][10] {
+		t.Error("Expected the middle and edge midpoints to be cleared")
+	}
+
+	// The margin grows the silhouette
+	tight := countModules(logoExcavation(logoSpec{image: createRoundLogo(200), margin: -1}, placement, size))
+	wide := countModules(logoExcavation(logoSpec{image: createRoundLogo(200), margin: 1.5}, placement, size))
+	if !(tight < n && n < wide) {
+		t.Errorf("Expected the cleared area to grow with the margin, got %d, %d, %d", tight, n, wide)
+	}
+
+	// A style's plate is part of the footprint
+	plated := logoExcavation(logoSpec{image: createRoundLogo(200), style: &LogoStyle{}}, placement, size)
+	if countModules(plated) != 11*11 {
+		t.Errorf("Expected a square plate to cover the whole area, got %d", countModules(plated))
+	}
+}
+
+func TestRoundLogoReadability(t *testing.T) {
+	qr, err := New("https://meet.google.com/abc-defg-hij", High)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	qr.SetLogo(createRoundLogo(200), 30)
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	img, err := qr.ToImage(StyleConfig{ModuleSize: 10, QuietZone: 40})
+	if err != nil {
+		t.Fatalf("Failed to generate image: %v", err)
+	}
+
+	// Modules survive in the corners of the logo area, around the disc
+	placement := optimizeLogoPlacement(&Matrix{Size: qr.Size}, qr.LogoSize, qr.Version)
+	kept := 0
+	for _, m := range [][2]int{
+		{placement.X, placement.Y},
+		{placement.X + placement.Width - 1, placement.Y},
+		{placement.X, placement.Y + placement.Height - 1},
+		{placement.X + placement.Width - 1, placement.Y + placement.Height - 1},
+	} {
+		if qr.Matrix[m[1]][m[0]] {
+			kept++
+		}
+	}
+	if kept == 0 {
+		t.Error("Expected dark modules to remain in the corners of the logo area")
+	}
+
+	saveTestImage(t, img, "test_logo_round_excavation.png")
+}
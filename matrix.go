@@ -94,6 +94,134 @@ func (m *Matrix) AddTimingPatterns() {
 	}
 }
 
+// alignmentPatternPositions holds the alignment pattern center coordinates for versions 1-40
+var alignmentPatternPositions = [][]int{
+	{},
+	{6, 18},
+	{6, 22},
+	{6, 26},
+	{6, 30},
+	{6, 34},
+	{6, 22, 38},
+	{6, 24, 42},
+	{6, 26, 46},
+	{6, 28, 50},
+	{6, 30, 54},
+	{6, 32, 58},
+	{6, 34, 62},
+	{6, 26, 46, 66},
+	{6, 26, 48, 70},
+	{6, 26, 50, 74},
+	{6, 30, 54, 78},
+	{6, 30, 56, 82},
+	{6, 30, 58, 86},
+	{6, 34, 62, 90},
+	{6, 28, 50, 72, 94},
+	{6, 26, 50, 74, 98},
+	{6, 30, 54, 78, 102},
+	{6, 28, 54, 80, 106},
+	{6, 32, 58, 84, 110},
+	{6, 30, 58, 86, 114},
+	{6, 34, 62, 90, 118},
+	{6, 26, 50, 74, 98, 122},
+	{6, 30, 54, 78, 102, 126},
+	{6, 26, 52, 78, 104, 130},
+	{6, 30, 56, 82, 108, 134},
+	{6, 34, 60, 86, 112, 138},
+	{6, 30, 58, 86, 114, 142},
+	{6, 34, 62, 90, 118, 146},
+	{6, 30, 54, 78, 102, 126, 150},
+	{6, 24, 50, 76, 102, 128, 154},
+	{6, 28, 54, 80, 106, 132, 158},
+	{6, 32, 58, 84, 110, 136, 162},
+	{6, 26, 54, 82, 110, 138, 166},
+	{6, 30, 58, 86, 114, 142, 170},
+}
+
+// alignmentPatternCenters returns the centers of all alignment patterns for a version,
+// skipping the three positions that would overlap the finder patterns
+func alignmentPatternCenters(version int) [][2]int {
+	if version < 1 || version > len(alignmentPatternPositions) {
+		return nil
+	}
+
+	positions := alignmentPatternPositions[version-1]
+	last := len(positions) - 1
+
+	var centers [][2]int
+	for i, y := range positions {
+		for j, x := range positions {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			centers = append(centers, [2]int{x, y})
+		}
+	}
+	return centers
+}
+
+func (m *Matrix) AddAlignmentPatterns() {
+	version := (m.Size - 17) / 4
+
+	for _, center := range alignmentPatternCenters(version) {
+		for dy := -2; dy <= 2; dy++ {
+			for dx := -2; dx <= 2; dx++ {
+				// Dark outer ring and center, light ring in between
+				value := max(abs(dx), abs(dy)) != 1
+				m.Set(center[0]+dx, center[1]+dy, value)
+				m.SetReserved(center[0]+dx, center[1]+dy)
+			}
+		}
+	}
+}
+
+// AddVersionInfo places the two 6x3 version information blocks (versions 7 and up)
+func (m *Matrix) AddVersionInfo() {
+	version := (m.Size - 17) / 4
+	if version < 7 {
+		return
+	}
+
+	versionBits := getVersionBits(version)
+
+	for i := 0; i < 18; i++ {
+		value := (versionBits>>i)&1 == 1
+		a := m.Size - 11 + i%3
+		b := i / 3
+
+		// Bottom-left block
+		m.Set(b, a, value)
+		m.SetReserved(b, a)
+
+		// Top-right block
+		m.Set(a, b, value)
+		m.SetReserved(a, b)
+	}
+}
+
+// getVersionBits returns the 18-bit version information: 6 version bits
+// followed by a 12-bit BCH(18,6) error correction code
+func getVersionBits(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	return version<<12 | rem
+}
+
+// ReserveFormatAreas reserves the format information modules so data is not
+// placed there. The actual bits are written by AddFormatInfo once the mask is known.
+func (m *Matrix) ReserveFormatAreas() {
+	for i := 0; i < 9; i++ {
+		m.SetReserved(8, i)
+		m.SetReserved(i, 8)
+	}
+	for i := 0; i < 8; i++ {
+		m.SetReserved(m.Size-1-i, 8)
+		m.SetReserved(8, m.Size-1-i)
+	}
+}
+
 func (m *Matrix) AddDarkModule() {
 	version := (m.Size - 17) / 4
 	x := 8
@@ -138,6 +266,22 @@ func (m *Matrix) AddFormatInfo(level ErrorCorrectionLevel, maskPattern int) {
 	}
 }
 
+// newSymbolMatrix creates a matrix for a version with every function pattern
+// placed and reserved, leaving only the data region free
+func newSymbolMatrix(version int) *Matrix {
+	info := getVersionInfo(version)
+
+	matrix := NewMatrix(info.Size)
+	matrix.AddFinderPatterns()
+	matrix.AddAlignmentPatterns()
+	matrix.AddTimingPatterns()
+	matrix.AddDarkModule()
+	matrix.AddVersionInfo()
+	matrix.ReserveFormatAreas()
+
+	return matrix
+}
+
 func getFormatBits(level ErrorCorrectionLevel, maskPattern int) int {
 	formatTable := map[string]int{
 		"L0": 0x77C4, "L1": 0x72F3, "L2": 0x7DAA, "L3": 0x789D,
@@ -0,0 +1,63 @@
+package myqrcode
+
+import (
+	"testing"
+
+	"rsc.io/qr/coding"
+)
+
+// TestMatrixMatchesReference compares encoded symbols with rsc.io/qr module
+// by module. Masks are chosen independently, so one of the eight reference
+// masks has to match exactly.
+func TestMatrixMatchesReference(t *testing.T) {
+	long := "https://example.com/archive/2024/05/a-rather-long-article-title-for-a-version-seven-symbol"
+	for _, tc := range []struct {
+		data    string
+		version int
+		level   ErrorCorrectionLevel
+	}{
+		{"hello", 1, Low},
+		{"https://example.com", 2, Medium},
+		{"https://example.com/some/longer/path", 3, Low},
+		{long, 7, Medium},
+		{long, 9, High},
+		{long + long, 10, Low},
+	} {
+		qr, err := New(tc.data, tc.level)
+		if err != nil {
+			t.Fatalf("Failed to create QR code: %v", err)
+		}
+		qr.Mode = Byte
+		qr.Version = tc.version
+		if err := qr.Encode(); err != nil {
+			t.Fatalf("Failed to encode version %d: %v", tc.version, err)
+		}
+
+		best, bestMask := -1, 0
+		for mask := 0; mask < 8; mask++ {
+			plan, err := coding.NewPlan(coding.Version(tc.version), coding.Level(tc.level), coding.Mask(mask))
+			if err != nil {
+				t.Fatalf("Failed to plan version %d: %v", tc.version, err)
+			}
+			ref, err := plan.Encode(coding.String(tc.data))
+			if err != nil {
+				t.Fatalf("Failed to encode reference version %d: %v", tc.version, err)
+			}
+
+			diff := 0
+			for y := 0; y < ref.Size; y++ {
+				for x := 0; x < ref.Size; x++ {
+					if ref.Black(x, y) != qr.Matrix[y][x] {
+						diff++
+					}
+				}
+			}
+			if best < 0 || diff < best {
+				best, bestMask = diff, mask
+			}
+		}
+		if best != 0 {
+			t.Errorf("Version %d: %d modules differ from the closest reference (mask %d)", tc.version, best, bestMask)
+		}
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"image"
 	"image/color"
 	"image/draw"
-	"math"
 )
 
 const AntialiasingFactor = 4
@@ -24,6 +23,27 @@ type ModuleDrawer interface {
 	NeedsNeighbors() bool
 }
 
+// ColoredModuleDrawer is implemented by drawers that can switch foreground
+// color between modules, which per-role colors (StyleConfig.RoleColors) need.
+// BaseModuleDrawer implements it for drawers that read the color at draw time.
+type ColoredModuleDrawer interface {
+	ModuleDrawer
+
+	// SetForegroundColor changes the color used for subsequent modules
+	SetForegroundColor(c color.Color)
+}
+
+// PositionedModuleDrawer is implemented by drawers that need each module's
+// grid position, e.g. to look up its role. ToImage calls DrawModuleAt
+// instead of DrawModule and, without a FinderDrawer, passes finder modules
+// to it as well.
+type PositionedModuleDrawer interface {
+	ModuleDrawer
+
+	// DrawModuleAt draws the module in column x, row y of the symbol
+	DrawModuleAt(box [4]int, x, y int, isActive bool, neighbors *ActiveWithNeighbors)
+}
+
 // ActiveWithNeighbors provides context about the 8 surrounding modules
 type ActiveWithNeighbors struct {
 	NW bool // Northwest
@@ -52,122 +72,78 @@ func (b *BaseModuleDrawer) NeedsNeighbors() bool {
 	return false
 }
 
-// createAntialiasingImage creates a larger image for anti-aliasing
-func createAntialiasingImage(size int, bgColor color.Color) *image.RGBA {
-	bigSize := size * AntialiasingFactor
-	img := image.NewRGBA(image.Rect(0, 0, bigSize, bigSize))
-
-	// Fill with background color
-	c := color.RGBAModel.Convert(bgColor).(color.RGBA)
-	for y := 0; y < bigSize; y++ {
-		for x := 0; x < bigSize; x++ {
-			img.Set(x, y, c)
-		}
-	}
-	return img
+func (b *BaseModuleDrawer) SetForegroundColor(c color.Color) {
+	b.config.ForegroundColor = c
 }
 
-// resizeImage downscales an image with anti-aliasing
-func resizeImage(src *image.RGBA, targetSize int) *image.RGBA {
-	srcBounds := src.Bounds()
-	dst := image.NewRGBA(image.Rect(0, 0, targetSize, targetSize))
-
-	// Simple bilinear downsampling
-	scale := float64(srcBounds.Dx()) / float64(targetSize)
-
-	for y := 0; y < targetSize; y++ {
-		for x := 0; x < targetSize; x++ {
-			srcX := float64(x) * scale
-			srcY := float64(y) * scale
+// RenderQuality selects how curved edges of modules, finders, logo plates
+// and frames are anti-aliased
+type RenderQuality int
 
-			// Get the four surrounding pixels
-			x1, y1 := int(srcX), int(srcY)
-			x2, y2 := x1+1, y1+1
+const (
+	QualityExact        RenderQuality = iota // Exact area coverage from the vector rasterizer
+	QualitySupersampled                      // AntialiasingFactor² point samples per pixel, averaged
+	QualityAliased                           // No anti-aliasing: every pixel fully on or off, for thermal printers
+)
 
-			// Clamp to bounds
-			if x2 >= srcBounds.Dx() {
-				x2 = srcBounds.Dx() - 1
-			}
-			if y2 >= srcBounds.Dy() {
-				y2 = srcBounds.Dy() - 1
-			}
+// coverage renders a shape's coverage mask at the quality. raster draws the
+// shape into a mask scale times the final size.
+func (q RenderQuality) coverage(raster func(scale int) *image.Alpha) *image.Alpha {
+	switch q {
+	case QualitySupersampled:
+		big := raster(AntialiasingFactor)
+		threshold(big)
+		return downsample(big, AntialiasingFactor)
+	case QualityAliased:
+		mask := raster(1)
+		threshold(mask)
+		return mask
+	}
+	return raster(1)
+}
 
-			// Get colors
-			c1 := src.RGBAAt(x1, y1)
-			c2 := src.RGBAAt(x2, y1)
-			c3 := src.RGBAAt(x1, y2)
-			c4 := src.RGBAAt(x2, y2)
-
-			// Interpolation weights
-			fx := srcX - float64(x1)
-			fy := srcY - float64(y1)
-
-			// Bilinear interpolation
-			r := uint8(float64(c1.R)*(1-fx)*(1-fy) + float64(c2.R)*fx*(1-fy) +
-				float64(c3.R)*(1-fx)*fy + float64(c4.R)*fx*fy)
-			g := uint8(float64(c1.G)*(1-fx)*(1-fy) + float64(c2.G)*fx*(1-fy) +
-				float64(c3.G)*(1-fx)*fy + float64(c4.G)*fx*fy)
-			b := uint8(float64(c1.B)*(1-fx)*(1-fy) + float64(c2.B)*fx*(1-fy) +
-				float64(c3.B)*(1-fx)*fy + float64(c4.B)*fx*fy)
-			a := uint8(float64(c1.A)*(1-fx)*(1-fy) + float64(c2.A)*fx*(1-fy) +
-				float64(c3.A)*(1-fx)*fy + float64(c4.A)*fx*fy)
-
-			dst.Set(x, y, color.RGBA{r, g, b, a})
+// threshold turns partial coverage into fully on or off pixels
+func threshold(mask *image.Alpha) {
+	for i, a := range mask.Pix {
+		if a >= 128 {
+			mask.Pix[i] = 255
+		} else {
+			mask.Pix[i] = 0
 		}
 	}
-
-	return dst
 }
 
-// resizeImageHighQuality provides higher quality resizing similar to Python's Lanczos
-func resizeImageHighQuality(src *image.RGBA, targetSize int) *image.RGBA {
-	srcBounds := src.Bounds()
-	dst := image.NewRGBA(image.Rect(0, 0, targetSize, targetSize))
-	
-	// Use a higher quality interpolation approach
-	scaleX := float64(srcBounds.Dx()) / float64(targetSize)
-	scaleY := float64(srcBounds.Dy()) / float64(targetSize)
-	
-	for y := 0; y < targetSize; y++ {
-		for x := 0; x < targetSize; x++ {
-			// Use more sophisticated sampling for better quality
-			srcX := float64(x) * scaleX
-			srcY := float64(y) * scaleY
-			
-			// Sample multiple points for better anti-aliasing (mimics Lanczos approach)
-			var totalR, totalG, totalB, totalA float64
-			var samples int
-			
-			for dy := -0.5; dy <= 0.5; dy += 0.25 {
-				for dx := -0.5; dx <= 0.5; dx += 0.25 {
-					sampleX := int(srcX + dx)
-					sampleY := int(srcY + dy)
-					
-					if sampleX >= 0 && sampleX < srcBounds.Dx() && sampleY >= 0 && sampleY < srcBounds.Dy() {
-						c := src.RGBAAt(sampleX, sampleY)
-						totalR += float64(c.R)
-						totalG += float64(c.G)
-						totalB += float64(c.B)
-						totalA += float64(c.A)
-						samples++
-					}
+// downsample averages each factor×factor block of src into one pixel
+func downsample(src *image.Alpha, factor int) *image.Alpha {
+	b := src.Bounds()
+	dst := image.NewAlpha(image.Rect(0, 0, b.Dx()/factor, b.Dy()/factor))
+	for y := 0; y < dst.Rect.Dy(); y++ {
+		for x := 0; x < dst.Rect.Dx(); x++ {
+			sum := 0
+			for sy := 0; sy < factor; sy++ {
+				row := src.PixOffset(b.Min.X+x*factor, b.Min.Y+y*factor+sy)
+				for _, a := range src.Pix[row : row+factor] {
+					sum += int(a)
 				}
 			}
-			
-			if samples > 0 {
-				dst.Set(x, y, color.RGBA{
-					uint8(totalR / float64(samples)),
-					uint8(totalG / float64(samples)),
-					uint8(totalB / float64(samples)),
-					uint8(totalA / float64(samples)),
-				})
-			}
+			dst.Pix[dst.PixOffset(x, y)] = uint8((sum + factor*factor/2) / (factor * factor))
 		}
 	}
-	
 	return dst
 }
 
+// renderSprite draws contours, given in pixels of a size×size sprite, in the
+// foreground color at the quality. Sprites keep no background: edges end up
+// as premultiplied partial alpha and are composited with draw.Over onto
+// whatever is underneath.
+func renderSprite(size int, fg color.Color, quality RenderQuality, contours ...*contour) *image.RGBA {
+	mask := contourMask(size, size, quality, contours)
+
+	sprite := image.NewRGBA(mask.Bounds())
+	draw.DrawMask(sprite, sprite.Bounds(), &image.Uniform{fg}, image.Point{}, mask, image.Point{}, draw.Src)
+	return sprite
+}
+
 // SquareModuleDrawer draws basic square modules
 type SquareModuleDrawer struct {
 	BaseModuleDrawer
@@ -183,7 +159,7 @@ func (s *SquareModuleDrawer) DrawModule(box [4]int, isActive bool, neighbors *Ac
 	}
 
 	rect := image.Rect(box[0], box[1], box[2], box[3])
-	draw.Draw(s.img, rect, &image.Uniform{s.config.ForegroundColor}, image.Point{}, draw.Src)
+	draw.Draw(s.img, rect, &image.Uniform{s.config.ForegroundColor}, image.Point{}, draw.Over)
 }
 
 // CircleModuleDrawer draws circular modules with anti-aliasing
@@ -201,29 +177,18 @@ func (c *CircleModuleDrawer) Initialize(img *image.RGBA, config StyleConfig) {
 	c.createCircle()
 }
 
-func (c *CircleModuleDrawer) createCircle() {
-	size := c.config.ModuleSize
-	bigImg := createAntialiasingImage(size, c.config.BackgroundColor)
-	bigSize := size * AntialiasingFactor
-	center := float64(bigSize) / 2
-	radius := center
-
-	fgColor := color.RGBAModel.Convert(c.config.ForegroundColor).(color.RGBA)
-
-	// Draw anti-aliased circle
-	for y := 0; y < bigSize; y++ {
-		for x := 0; x < bigSize; x++ {
-			dx := float64(x) - center + 0.5
-			dy := float64(y) - center + 0.5
-			distance := math.Sqrt(dx*dx + dy*dy)
-
-			if distance <= radius {
-				bigImg.Set(x, y, fgColor)
-			}
-		}
+func (c *CircleModuleDrawer) SetForegroundColor(fg color.Color) {
+	if fg == c.config.ForegroundColor {
+		return
 	}
+	c.BaseModuleDrawer.SetForegroundColor(fg)
+	c.createCircle()
+}
 
-	c.circle = resizeImage(bigImg, size)
+func (c *CircleModuleDrawer) createCircle() {
+	size := c.config.ModuleSize
+	r := float32(size) / 2
+	c.circle = renderSprite(size, c.config.ForegroundColor, c.config.Quality, circle(r, r, r))
 }
 
 func (c *CircleModuleDrawer) DrawModule(box [4]int, isActive bool, neighbors *ActiveWithNeighbors) {
@@ -234,7 +199,7 @@ func (c *CircleModuleDrawer) DrawModule(box [4]int, isActive bool, neighbors *Ac
 	// Paste the pre-rendered circle
 	dst := image.Rect(box[0], box[1], box[2], box[3])
 	src := c.circle.Bounds()
-	draw.Draw(c.img, dst, c.circle, src.Min, draw.Src)
+	draw.Draw(c.img, dst, c.circle, src.Min, draw.Over)
 }
 
 // GappedSquareModuleDrawer draws squares with configurable gaps
@@ -267,7 +232,7 @@ func (g *GappedSquareModuleDrawer) DrawModule(box [4]int, isActive bool, neighbo
 		box[3]-delta,
 	)
 
-	draw.Draw(g.img, smallerBox, &image.Uniform{g.config.ForegroundColor}, image.Point{}, draw.Src)
+	draw.Draw(g.img, smallerBox, &image.Uniform{g.config.ForegroundColor}, image.Point{}, draw.Over)
 }
 
 // GappedCircleModuleDrawer draws circles with configurable gaps
@@ -289,36 +254,19 @@ func (g *GappedCircleModuleDrawer) Initialize(img *image.RGBA, config StyleConfi
 	g.createGappedCircle()
 }
 
-func (g *GappedCircleModuleDrawer) createGappedCircle() {
-	size := g.config.ModuleSize
-	
-	// Step 1: Create full-size anti-aliased circle (like Python's approach)
-	bigImg := createAntialiasingImage(size, g.config.BackgroundColor)
-	bigSize := size * AntialiasingFactor
-	center := float64(bigSize) / 2
-	radius := center
-
-	fgColor := color.RGBAModel.Convert(g.config.ForegroundColor).(color.RGBA)
-
-	// Draw full anti-aliased circle
-	for y := 0; y < bigSize; y++ {
-		for x := 0; x < bigSize; x++ {
-			dx := float64(x) - center + 0.5
-			dy := float64(y) - center + 0.5
-			distance := math.Sqrt(dx*dx + dy*dy)
-
-			if distance <= radius {
-				bigImg.Set(x, y, fgColor)
-			}
-		}
+func (g *GappedCircleModuleDrawer) SetForegroundColor(fg color.Color) {
+	if fg == g.config.ForegroundColor {
+		return
 	}
+	g.BaseModuleDrawer.SetForegroundColor(fg)
+	g.createGappedCircle()
+}
 
-	// Step 2: Resize to full module size first (preserves anti-aliasing)
-	fullSizeCircle := resizeImage(bigImg, size)
-	
-	// Step 3: Resize again to the gapped size (like Python's single resize)
-	actualSize := int(float64(size) * g.SizeRatio)
-	g.circle = resizeImageHighQuality(fullSizeCircle, actualSize)
+func (g *GappedCircleModuleDrawer) createGappedCircle() {
+	// The circle is centered in a full module sprite, so odd gaps split evenly
+	size := g.config.ModuleSize
+	center := float32(size) / 2
+	g.circle = renderSprite(size, g.config.ForegroundColor, g.config.Quality, circle(center, center, center*float32(g.SizeRatio)))
 }
 
 func (g *GappedCircleModuleDrawer) DrawModule(box [4]int, isActive bool, neighbors *ActiveWithNeighbors) {
@@ -326,21 +274,8 @@ func (g *GappedCircleModuleDrawer) DrawModule(box [4]int, isActive bool, neighbo
 		return
 	}
 
-	// Use Python's approach: simple top-left positioning (like paste)
-	actualSize := g.circle.Bounds().Dx()
-	
-	// Center the circle within the module box
-	width := box[2] - box[0]
-	offset := (width - actualSize) / 2
-
-	dst := image.Rect(
-		box[0]+offset,
-		box[1]+offset,
-		box[0]+offset+actualSize,
-		box[1]+offset+actualSize,
-	)
-
-	draw.Draw(g.img, dst, g.circle, g.circle.Bounds().Min, draw.Src)
+	dst := image.Rect(box[0], box[1], box[2], box[3])
+	draw.Draw(g.img, dst, g.circle, g.circle.Bounds().Min, draw.Over)
 }
 
 // RoundedModuleDrawer draws modules with context-aware rounded corners
@@ -373,8 +308,15 @@ func (r *RoundedModuleDrawer) Initialize(img *image.RGBA, config StyleConfig) {
 	r.setupCorners()
 }
 
+func (r *RoundedModuleDrawer) SetForegroundColor(fg color.Color) {
+	if fg == r.config.ForegroundColor {
+		return
+	}
+	r.BaseModuleDrawer.SetForegroundColor(fg)
+	r.setupCorners()
+}
+
 func (r *RoundedModuleDrawer) setupCorners() {
-	bgColor := r.config.BackgroundColor
 	fgColor := r.config.ForegroundColor
 
 	// Create square corner (no rounding)
@@ -386,40 +328,17 @@ func (r *RoundedModuleDrawer) setupCorners() {
 		}
 	}
 
-	// Create rounded corners with anti-aliasing
-	fakeWidth := r.cornerWidth * AntialiasingFactor
-	radius := r.RadiusRatio * float64(fakeWidth)
-
-	// Create base image for northwest rounded corner
-	base := createAntialiasingImage(r.cornerWidth, bgColor)
-	bigSize := r.cornerWidth * AntialiasingFactor
-
-	// Draw rounded corner: circle in top-left, rectangles extending right and down
-	for y := 0; y < bigSize; y++ {
-		for x := 0; x < bigSize; x++ {
-			// Check if pixel is inside the rounded corner shape
-			dx := float64(x)
-			dy := float64(y)
-
-			// Always fill if we're in the rectangle extensions
-			inRightRect := dx >= radius && x < bigSize
-			inBottomRect := dy >= radius && y < bigSize
-
-			// Check if we're in the circular part
-			inCircle := false
-			if dx < radius && dy < radius {
-				distFromCenter := math.Sqrt(dx*dx + dy*dy)
-				inCircle = distFromCenter <= radius
-			}
-
-			if inCircle || inRightRect || inBottomRect {
-				base.Set(x, y, fgRGBA)
-			}
-		}
-	}
-
-	// Resize to actual size
-	r.nwRound = resizeImage(base, r.cornerWidth)
+	// The northwest corner is a quarter circle centered radius away from
+	// the outer corner, with the rest of the square filled
+	w := float32(r.cornerWidth)
+	radius := float32(r.RadiusRatio) * w
+	k := float32(kappa) * radius
+	corner := &contour{start: point{0, radius}}
+	corner.cubeTo(0, radius-k, radius-k, 0, radius, 0)
+	corner.lineTo(w, 0)
+	corner.lineTo(w, w)
+	corner.lineTo(0, w)
+	r.nwRound = renderSprite(r.cornerWidth, fgColor, r.config.Quality, corner)
 
 	// Create other corners by rotating/flipping
 	r.neRound = r.flipHorizontal(r.nwRound)
@@ -502,17 +421,17 @@ func (r *RoundedModuleDrawer) DrawModule(box [4]int, isActive bool, neighbors *A
 	// Draw the four corners
 	// Northwest corner
 	nwDst := image.Rect(box[0], box[1], box[0]+r.cornerWidth, box[1]+r.cornerWidth)
-	draw.Draw(r.img, nwDst, nw, nw.Bounds().Min, draw.Src)
+	draw.Draw(r.img, nwDst, nw, nw.Bounds().Min, draw.Over)
 
 	// Northeast corner
 	neDst := image.Rect(box[0]+r.cornerWidth, box[1], box[2], box[1]+r.cornerWidth)
-	draw.Draw(r.img, neDst, ne, ne.Bounds().Min, draw.Src)
+	draw.Draw(r.img, neDst, ne, ne.Bounds().Min, draw.Over)
 
 	// Southeast corner
 	seDst := image.Rect(box[0]+r.cornerWidth, box[1]+r.cornerWidth, box[2], box[3])
-	draw.Draw(r.img, seDst, se, se.Bounds().Min, draw.Src)
+	draw.Draw(r.img, seDst, se, se.Bounds().Min, draw.Over)
 
 	// Southwest corner
 	swDst := image.Rect(box[0], box[1]+r.cornerWidth, box[0]+r.cornerWidth, box[3])
-	draw.Draw(r.img, swDst, sw, sw.Bounds().Min, draw.Src)
+	draw.Draw(r.img, swDst, sw, sw.Bounds().Min, draw.Over)
 }
@@ -0,0 +1,171 @@
+package myqrcode
+
+import (
+	"fmt"
+	"image"
+)
+
+// LogoAnchor selects where a logo or overlay sits on the symbol
+type LogoAnchor int
+
+const (
+	AnchorCenter      LogoAnchor = iota // Centered, nudged off function patterns if needed
+	AnchorBottomRight                   // Badge in the bottom-right corner, the one without a finder
+	AnchorCustom                        // Top-left module at X, Y
+)
+
+// Overlay is an image placed on the symbol. The logo set with SetLogo is
+// the first overlay; AddOverlay adds more. All overlays share one error
+// budget, so Encode sizes the symbol for their combined damage.
+type Overlay struct {
+	Image  image.Image
+	Size   int // Percentage of the symbol width
+	Anchor LogoAnchor
+	X, Y   int // AnchorCustom: top-left module
+
+	Style   *LogoStyle // Clip shape, plate and border (nil: the image as is)
+	Mode    LogoMode
+	Opacity float64 // LogoBlend: between 0 and 1, exclusive; otherwise 0 or 1 is opaque
+	Margin  float64 // Light margin around the silhouette in modules (0: half a module, negative: none)
+}
+
+// AddOverlay places another image on the symbol, such as a corner badge
+func (qr *QRCode) AddOverlay(overlay Overlay) {
+	qr.Overlays = append(qr.Overlays, overlay)
+}
+
+// SetLogoAnchor moves the logo away from the center. x and y are the
+// top-left module for AnchorCustom and ignored otherwise.
+func (qr *QRCode) SetLogoAnchor(anchor LogoAnchor, x, y int) {
+	qr.LogoAnchor = anchor
+	qr.LogoX, qr.LogoY = x, y
+}
+
+// logoOverlay returns the logo set with SetLogo and friends as an overlay
+func (qr *QRCode) logoOverlay(img image.Image, size int) Overlay {
+	return Overlay{
+		Image:   img,
+		Size:    size,
+		Anchor:  qr.LogoAnchor,
+		X:       qr.LogoX,
+		Y:       qr.LogoY,
+		Style:   qr.LogoStyle,
+		Mode:    qr.LogoMode,
+		Opacity: qr.LogoOpacity,
+		Margin:  qr.LogoMargin,
+	}
+}
+
+// validate rejects a blended overlay that would not show the modules under it
+func (o Overlay) validate() error {
+	if o.Mode == LogoBlend && (o.Opacity <= 0 || o.Opacity >= 1) {
+		return fmt.Errorf("blend opacity %v must be between 0 and 1, exclusive", o.Opacity)
+	}
+	return nil
+}
+
+// spec returns what drawing and measuring the overlay needs
+func (o Overlay) spec() logoSpec {
+	return logoSpec{image: o.Image, style: o.Style, mode: o.Mode, opacity: o.Opacity, margin: o.Margin}
+}
+
+// overlays returns the logo and every added overlay that has an image
+func (qr *QRCode) overlays() []Overlay {
+	var overlays []Overlay
+	if qr.Logo != nil && qr.LogoSize > 0 {
+		overlays = append(overlays, qr.logoOverlay(qr.Logo, qr.LogoSize))
+	}
+	for _, o := range qr.Overlays {
+		if o.Image != nil && o.Size > 0 {
+			overlays = append(overlays, o)
+		}
+	}
+	return overlays
+}
+
+// placement returns the overlay's module area in a symbol of the given version
+func (o Overlay) placement(version int) LogoPlacement {
+	size := getVersionInfo(version).Size
+	switch o.Anchor {
+	case AnchorBottomRight:
+		p := calculateLogoPlacement(&Matrix{Size: size}, o.Size)
+		p.X, p.Y = size-p.Width, size-p.Height
+		return p
+	case AnchorCustom:
+		p := calculateLogoPlacement(&Matrix{Size: size}, o.Size)
+		p.X, p.Y = o.X, o.Y
+		return p
+	default:
+		return optimizeLogoPlacement(&Matrix{Size: size}, o.Size, version)
+	}
+}
+
+// overlayFootprints combines the footprints of all overlays in a symbol of
+// the given version. It returns the modules counted against the error
+// budget and the ones to clear, or false when an overlay leaves the symbol
+// or covers a finder, timing or format pattern. Alignment modules are
+// neither: they are kept and drawn on top of the overlays.
+func overlayFootprints(overlays []Overlay, version int) (budget, cleared [][]bool, ok bool) {
+	size := getVersionInfo(version).Size
+	roles, err := ClassifyModules(version)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	budget = make([][]bool, size)
+	cleared = make([][]bool, size)
+	for y := range budget {
+		budget[y] = make([]bool, size)
+		cleared[y] = make([]bool, size)
+	}
+
+	for _, o := range overlays {
+		p := o.placement(version)
+		if p.X < 0 || p.Y < 0 || p.X+p.Width > size || p.Y+p.Height > size {
+			return nil, nil, false
+		}
+
+		mask := logoFootprint(o.spec(), p, size)
+		for y := range mask {
+			for x, covered := range mask[y] {
+				if !covered {
+					continue
+				}
+				switch roles[y][x] {
+				case RoleData:
+				case RoleAlignment:
+					continue // Kept and drawn over the overlay
+				default:
+					return nil, nil, false
+				}
+				budget[y][x] = true
+				if o.Mode == LogoExcavate {
+					cleared[y][x] = true
+				}
+			}
+		}
+	}
+
+	return budget, cleared, true
+}
+
+// alignmentUnder returns the alignment modules inside the placements, whose
+// dark modules ToImage and ToSVG draw again on top of the overlays
+func (qr *QRCode) alignmentUnder(placements []LogoPlacement) []image.Point {
+	roles := qr.ModuleRoles()
+	var modules []image.Point
+	for y := 0; y < qr.Size; y++ {
+		for x := 0; x < qr.Size; x++ {
+			if roles[y][x] != RoleAlignment {
+				continue
+			}
+			for _, p := range placements {
+				if isLogoArea(x, y, p) {
+					modules = append(modules, image.Pt(x, y))
+					break
+				}
+			}
+		}
+	}
+	return modules
+}
@@ -0,0 +1,167 @@
+package myqrcode
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestOverlayPlacement(t *testing.T) {
+	version := 5 // 37 x 37
+	center := Overlay{Size: 20}.placement(version)
+	if center.X != 15 || center.Y != 15 || center.Width != 7 {
+		t.Errorf("Expected a centered 7x7 area at (15, 15), got %+v", center)
+	}
+
+	badge := Overlay{Size: 20, Anchor: AnchorBottomRight}.placement(version)
+	if badge.X != 30 || badge.Y != 30 {
+		t.Errorf("Expected the badge in the bottom-right corner at (30, 30), got %+v", badge)
+	}
+
+	custom := Overlay{Size: 20, Anchor: AnchorCustom, X: 12, Y: 25}.placement(version)
+	if custom.X != 12 || custom.Y != 25 {
+		t.Errorf("Expected the custom area at (12, 25), got %+v", custom)
+	}
+
+	// Overlays over a finder pattern or outside the symbol never fit
+	if _, _, ok := overlayFootprints([]Overlay{{Image: createTestLogo(40), Size: 20, Anchor: AnchorCustom}}, version); ok {
+		t.Error("Expected an overlay on the top-left finder to be rejected")
+	}
+	if _, _, ok := overlayFootprints([]Overlay{{Image: createTestLogo(40), Size: 20, Anchor: AnchorCustom, X: 34, Y: 20}}, version); ok {
+		t.Error("Expected an overlay reaching past the edge to be rejected")
+	}
+}
+
+func TestMultipleOverlays(t *testing.T) {
+	data := "https://meet.google.com/abc-defg-hij"
+	badge := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	draw.Draw(badge, badge.Bounds(), image.NewUniform(color.RGBA{230, 120, 20, 255}), image.Point{}, draw.Src)
+
+	qr, err := New(data, Medium)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	qr.SetLogo(createRoundLogo(200), 25)
+	qr.AddOverlay(Overlay{Image: badge, Size: 15, Anchor: AnchorBottomRight})
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	// Both overlays are cleared and budgeted together, except for the
+	// alignment modules under them
+	logo := qr.overlays()[0]
+	alone := countModules(logoFootprint(logo.spec(), logo.placement(qr.Version), qr.Size))
+	corner := qr.Overlays[0].placement(qr.Version)
+	kept := qr.alignmentUnder([]LogoPlacement{logo.placement(qr.Version), corner})
+	if len(kept) == 0 {
+		t.Fatal("Expected the badge to cover part of the alignment pattern")
+	}
+	if got, want := countModules(qr.cleared), alone+corner.Width*corner.Height-len(kept); got != want {
+		t.Errorf("Expected %d cleared modules, got %d", want, got)
+	}
+	footprint, _, _ := overlayFootprints(qr.overlays(), qr.Version)
+	if !withinBudget(logoDamage(qr.Version, qr.ErrorCorrection, footprint), 0) {
+		t.Error("Expected the combined damage to stay within the budget")
+	}
+
+	img, err := qr.ToImage(StyleConfig{ModuleSize: 10, QuietZone: 40})
+	if err != nil {
+		t.Fatalf("Failed to generate image: %v", err)
+	}
+
+	// The badge sits in the corner
+	x, y := 40+(corner.X+corner.Width/2)*10, 40+(corner.Y+corner.Height/2)*10
+	if !sameColor(img.At(x, y), color.RGBA{230, 120, 20, 255}) {
+		t.Errorf("Expected the badge at (%d, %d), got %v", x, y, img.At(x, y))
+	}
+
+	// The dark alignment modules under the badge are drawn on top of it
+	for _, m := range kept {
+		if qr.cleared[m.Y][m.X] {
+			t.Errorf("Expected alignment module (%d, %d) to be kept", m.X, m.Y)
+		}
+		want := color.Color(color.RGBA{230, 120, 20, 255})
+		if qr.Matrix[m.Y][m.X] {
+			want = color.Black
+		}
+		x, y := 40+m.X*10+5, 40+m.Y*10+5
+		if !sameColor(img.At(x, y), want) {
+			t.Errorf("Expected alignment module (%d, %d) to be %v, got %v", m.X, m.Y, want, img.At(x, y))
+		}
+	}
+
+	saveTestImage(t, img, "test_logo_overlays.png")
+
+	// An overlay on a finder pattern fails at every version
+	qr, err = New(data, Medium)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	qr.SetLogo(badge, 15)
+	qr.SetLogoAnchor(AnchorCustom, 0, 0)
+	if err := qr.Encode(); err == nil {
+		t.Error("Expected an error for a logo on the finder pattern")
+	}
+}
+
+func TestAlignmentOverLogo(t *testing.T) {
+	red := color.RGBA{200, 30, 30, 255}
+	blue := color.RGBA{20, 40, 160, 255}
+	logo := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	draw.Draw(logo, logo.Bounds(), image.NewUniform(red), image.Point{}, draw.Src)
+
+	// Version 7 puts an alignment pattern in the middle, under the logo
+	qr, err := New("https://example.com/alignment", High)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	qr.Version = 7
+	qr.SetLogo(logo, 20)
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+	center := qr.Size / 2
+	if len(qr.alignmentUnder([]LogoPlacement{qr.overlays()[0].placement(qr.Version)})) != 25 {
+		t.Fatal("Expected the logo to cover the middle alignment pattern")
+	}
+	at := func(img image.Image, x, y int, dx, dy float64) color.Color {
+		return img.At(40+x*10+int(dx*10), 40+y*10+int(dy*10))
+	}
+
+	// Dark modules keep the drawer's shape and the alignment color; the logo
+	// shows around them and through the light ring
+	config := StyleConfig{
+		ModuleSize:   10,
+		QuietZone:    40,
+		ModuleDrawer: NewCircleModuleDrawer(),
+		RoleColors:   map[ModuleRole]color.Color{RoleAlignment: blue},
+	}
+	img, err := qr.ToImage(config)
+	if err != nil {
+		t.Fatalf("Failed to generate image: %v", err)
+	}
+	if got := at(img, center, center, 0.5, 0.5); !sameColor(got, blue) {
+		t.Errorf("Expected the alignment center to be %v, got %v", blue, got)
+	}
+	if got := at(img, center, center, 0.05, 0.05); !sameColor(got, red) {
+		t.Errorf("Expected the logo around the round module, got %v", got)
+	}
+	if got := at(img, center-1, center, 0.5, 0.5); !sameColor(got, red) {
+		t.Errorf("Expected the logo through the light ring, got %v", got)
+	}
+
+	// Without a role color the modules take the fill
+	config.RoleColors = nil
+	config.Fill = NewLinearGradient(0, ColorStop{0, color.Black}, ColorStop{1, blue})
+	img, err = qr.ToImage(config)
+	if err != nil {
+		t.Fatalf("Failed to generate image: %v", err)
+	}
+	symbolSize := qr.Size * 10
+	paint := config.Fill.Image(image.Rect(40, 40, 40+symbolSize, 40+symbolSize))
+	x, y := 40+center*10+5, 40+center*10+5
+	if got, want := img.At(x, y), paint.At(x, y); !sameColor(got, want) {
+		t.Errorf("Expected the alignment center in the fill color %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,60 @@
+package payload
+
+import (
+	"fmt"
+	"strings"
+)
+
+// base45Charset is the RFC 9285 alphabet, identical to the QR alphanumeric set
+const base45Charset = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// EncodeBase45 encodes binary data as base45 (RFC 9285) so it can be stored
+// in a QR code using Alphanumeric mode
+func EncodeBase45(data []byte) string {
+	var sb strings.Builder
+	for i := 0; i+1 < len(data); i += 2 {
+		n := int(data[i])*256 + int(data[i+1])
+		sb.WriteByte(base45Charset[n%45])
+		sb.WriteByte(base45Charset[n/45%45])
+		sb.WriteByte(base45Charset[n/(45*45)])
+	}
+	if len(data)%2 == 1 {
+		n := int(data[len(data)-1])
+		sb.WriteByte(base45Charset[n%45])
+		sb.WriteByte(base45Charset[n/45])
+	}
+	return sb.String()
+}
+
+// DecodeBase45 decodes a base45 (RFC 9285) string
+func DecodeBase45(s string) ([]byte, error) {
+	if len(s)%3 == 1 {
+		return nil, fmt.Errorf("invalid base45 length %d", len(s))
+	}
+
+	values := make([]int, len(s))
+	for i := 0; i < len(s); i++ {
+		values[i] = strings.IndexByte(base45Charset, s[i])
+		if values[i] < 0 {
+			return nil, fmt.Errorf("invalid base45 character %q", s[i])
+		}
+	}
+
+	result := make([]byte, 0, len(s)*2/3)
+	for i := 0; i < len(values); i += 3 {
+		if i+2 < len(values) {
+			n := values[i] + values[i+1]*45 + values[i+2]*45*45
+			if n > 0xFFFF {
+				return nil, fmt.Errorf("invalid base45 group %q", s[i:i+3])
+			}
+			result = append(result, byte(n>>8), byte(n))
+		} else {
+			n := values[i] + values[i+1]*45
+			if n > 0xFF {
+				return nil, fmt.Errorf("invalid base45 group %q", s[i:i+2])
+			}
+			result = append(result, byte(n))
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,63 @@
+package payload
+
+import (
+	"strings"
+	"testing"
+)
+
+var testSecret = []byte("12345678901234567890")
+
+func TestOTPBuild(t *testing.T) {
+	got, err := OTP{Issuer: "ACME Co", Account: "john@example.com", Secret: testSecret}.Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	want := "otpauth://totp/ACME%20Co:john%40example.com?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&issuer=ACME%20Co"
+	if got != want {
+		t.Errorf("Build() = %q, want %q", got, want)
+	}
+}
+
+func TestOTPNonDefaultParameters(t *testing.T) {
+	got, err := OTP{
+		Issuer:    "ACME",
+		Account:   "john",
+		Secret:    testSecret,
+		Algorithm: SHA256,
+		Digits:    8,
+		Period:    60,
+	}.Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	for _, param := range []string{"algorithm=SHA256", "digits=8", "period=60"} {
+		if !strings.Contains(got, param) {
+			t.Errorf("Build() = %q, missing %q", got, param)
+		}
+	}
+}
+
+func TestOTPValidation(t *testing.T) {
+	testCases := []struct {
+		name string
+		otp  OTP
+	}{
+		{"Missing issuer", OTP{Account: "john", Secret: testSecret}},
+		{"Missing account", OTP{Issuer: "ACME", Secret: testSecret}},
+		{"Colon in issuer", OTP{Issuer: "ACME:Corp", Account: "john", Secret: testSecret}},
+		{"Short secret", OTP{Issuer: "ACME", Account: "john", Secret: []byte("short")}},
+		{"Unknown algorithm", OTP{Issuer: "ACME", Account: "john", Secret: testSecret, Algorithm: "MD5"}},
+		{"Too many digits", OTP{Issuer: "ACME", Account: "john", Secret: testSecret, Digits: 10}},
+		{"Negative period", OTP{Issuer: "ACME", Account: "john", Secret: testSecret, Period: -30}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tc.otp.Build(); err == nil {
+				t.Error("Expected validation error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,301 @@
+// Package payload builds the URI strings commonly embedded in QR codes.
+//
+// Every builder percent-encodes user supplied values the same way and, where
+// the target scheme is case-insensitive, emits an uppercase-only form so the
+// QR encoder can pick Alphanumeric mode and produce a smaller symbol.
+package payload
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Builder is implemented by every payload type in this package
+type Builder interface {
+	// Build validates the payload and returns the string to encode
+	Build() (string, error)
+}
+
+// Email builds a mailto: URI (RFC 6068)
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+func (e Email) Build() (string, error) {
+	if e.To == "" {
+		return "", errors.New("email recipient cannot be empty")
+	}
+	if strings.Count(e.To, "@") != 1 {
+		return "", fmt.Errorf("invalid email address %q", e.To)
+	}
+
+	uri := "mailto:" + escapeExcept(e.To, "@")
+	return uri + query(
+		"subject", e.Subject,
+		"body", e.Body,
+	), nil
+}
+
+// Phone builds a tel: URI (RFC 3966)
+type Phone struct {
+	Number string
+}
+
+func (p Phone) Build() (string, error) {
+	number, err := normalizePhone(p.Number)
+	if err != nil {
+		return "", err
+	}
+	return "TEL:" + number, nil
+}
+
+// SMS builds an sms: URI (RFC 5724)
+type SMS struct {
+	Number  string
+	Message string
+}
+
+func (s SMS) Build() (string, error) {
+	number, err := normalizePhone(s.Number)
+	if err != nil {
+		return "", err
+	}
+
+	// The body parameter is lowercase by definition, so only a bare number
+	// can use the uppercase form
+	if s.Message == "" {
+		return "SMS:" + number, nil
+	}
+	return "sms:" + number + query("body", s.Message), nil
+}
+
+// Geo builds a geo: URI (RFC 5870)
+type Geo struct {
+	Latitude  float64
+	Longitude float64
+	Altitude  float64 // Optional, omitted when zero
+}
+
+func (g Geo) Build() (string, error) {
+	// NaN fails every comparison, so it is checked explicitly
+	if math.IsNaN(g.Latitude) || g.Latitude < -90 || g.Latitude > 90 {
+		return "", fmt.Errorf("latitude %v out of range", g.Latitude)
+	}
+	if math.IsNaN(g.Longitude) || g.Longitude < -180 || g.Longitude > 180 {
+		return "", fmt.Errorf("longitude %v out of range", g.Longitude)
+	}
+	if math.IsNaN(g.Altitude) || math.IsInf(g.Altitude, 0) {
+		return "", fmt.Errorf("altitude %v is not a finite number", g.Altitude)
+	}
+
+	// The comma separator is outside the alphanumeric set, so there is no
+	// benefit in uppercasing the scheme
+	uri := "geo:" + formatFloat(g.Latitude) + "," + formatFloat(g.Longitude)
+	if g.Altitude != 0 {
+		uri += "," + formatFloat(g.Altitude)
+	}
+	return uri, nil
+}
+
+// WhatsApp builds a wa.me click-to-chat link
+type WhatsApp struct {
+	Number  string
+	Message string
+}
+
+func (w WhatsApp) Build() (string, error) {
+	number, err := normalizePhone(w.Number)
+	if err != nil {
+		return "", err
+	}
+	// wa.me expects the full international number without the leading plus
+	number = strings.TrimPrefix(number, "+")
+
+	if w.Message == "" {
+		return "HTTPS://WA.ME/" + number, nil
+	}
+	return "https://wa.me/" + number + query("text", w.Message), nil
+}
+
+// Bitcoin builds a BIP21 payment URI
+type Bitcoin struct {
+	Address string
+	Amount  int64 // Amount in satoshis, omitted when zero
+	Label   string
+	Message string
+}
+
+func (b Bitcoin) Build() (string, error) {
+	if b.Address == "" {
+		return "", errors.New("bitcoin address cannot be empty")
+	}
+	if b.Amount < 0 {
+		return "", errors.New("bitcoin amount cannot be negative")
+	}
+	if !isAlnum(b.Address) {
+		return "", fmt.Errorf("invalid bitcoin address %q", b.Address)
+	}
+
+	params := query(
+		"amount", formatSatoshis(b.Amount),
+		"label", b.Label,
+		"message", b.Message,
+	)
+
+	// Bech32 addresses are case-insensitive and BIP173 recommends the
+	// uppercase form for QR codes. Base58 addresses are case-sensitive.
+	if isBech32Address(b.Address) {
+		if params == "" {
+			return "BITCOIN:" + strings.ToUpper(b.Address), nil
+		}
+		return "bitcoin:" + strings.ToLower(b.Address) + params, nil
+	}
+	return "bitcoin:" + b.Address + params, nil
+}
+
+// Ethereum builds an EIP-681 payment request URI
+type Ethereum struct {
+	Address string
+	ChainID int64    // Optional, omitted when zero
+	Value   *big.Int // Amount in wei, omitted when nil
+}
+
+func (e Ethereum) Build() (string, error) {
+	if !isEthereumAddress(e.Address) {
+		return "", fmt.Errorf("invalid ethereum address %q", e.Address)
+	}
+	if e.ChainID < 0 {
+		return "", errors.New("chain id cannot be negative")
+	}
+	if e.Value != nil && e.Value.Sign() < 0 {
+		return "", errors.New("ethereum value cannot be negative")
+	}
+
+	// The address keeps its EIP-55 checksum casing, so no uppercase form
+	uri := "ethereum:" + e.Address
+	if e.ChainID != 0 {
+		uri += "@" + strconv.FormatInt(e.ChainID, 10)
+	}
+	if e.Value != nil {
+		uri += query("value", e.Value.String())
+	}
+	return uri, nil
+}
+
+// query builds a "?k=v&k=v" string from key/value pairs, skipping empty values
+func query(pairs ...string) string {
+	var parts []string
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] == "" {
+			continue
+		}
+		parts = append(parts, pairs[i]+"="+escape(pairs[i+1]))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "?" + strings.Join(parts, "&")
+}
+
+// escape percent-encodes everything except RFC 3986 unreserved characters
+func escape(s string) string {
+	return escapeExcept(s, "")
+}
+
+// escapeExcept percent-encodes like escape but leaves the given characters as-is.
+// Hex digits are always uppercase so encoded text stays alphanumeric-friendly.
+func escapeExcept(s, keep string) string {
+	const hex = "0123456789ABCDEF"
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isUnreserved(c) || strings.IndexByte(keep, c) >= 0 {
+			sb.WriteByte(c)
+			continue
+		}
+		sb.WriteByte('%')
+		sb.WriteByte(hex[c>>4])
+		sb.WriteByte(hex[c&0x0F])
+	}
+	return sb.String()
+}
+
+func isUnreserved(c byte) bool {
+	return (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isAlnum(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !((c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizePhone strips visual separators and keeps digits and a leading plus
+func normalizePhone(number string) (string, error) {
+	var sb strings.Builder
+	for i, c := range strings.TrimSpace(number) {
+		switch {
+		case c >= '0' && c <= '9':
+			sb.WriteRune(c)
+		case c == '+' && i == 0:
+			sb.WriteRune(c)
+		case c == ' ' || c == '-' || c == '.' || c == '(' || c == ')':
+			// Visual separators are dropped
+		default:
+			return "", fmt.Errorf("invalid character %q in phone number", c)
+		}
+	}
+
+	result := sb.String()
+	if strings.TrimPrefix(result, "+") == "" {
+		return "", errors.New("phone number cannot be empty")
+	}
+	return result, nil
+}
+
+func isBech32Address(address string) bool {
+	lower := strings.ToLower(address)
+	if address != lower && address != strings.ToUpper(address) {
+		return false // Mixed case is invalid for bech32
+	}
+	return strings.HasPrefix(lower, "bc1") || strings.HasPrefix(lower, "tb1") ||
+		strings.HasPrefix(lower, "bcrt1")
+}
+
+func isEthereumAddress(address string) bool {
+	if len(address) != 42 || !strings.HasPrefix(address, "0x") {
+		return false
+	}
+	for _, c := range address[2:] {
+		if !((c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')) {
+			return false
+		}
+	}
+	return true
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// formatSatoshis renders an amount in satoshis as a decimal BTC value
+func formatSatoshis(sats int64) string {
+	if sats == 0 {
+		return ""
+	}
+	s := fmt.Sprintf("%d.%08d", sats/100000000, sats%100000000)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
@@ -0,0 +1,106 @@
+package payload
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/juparave/myqrcode"
+)
+
+func TestBuilders(t *testing.T) {
+	testCases := []struct {
+		name    string
+		builder Builder
+		want    string
+	}{
+		{"Email", Email{To: "john@example.com"}, "mailto:john@example.com"},
+		{"Email with subject", Email{To: "john@example.com", Subject: "Hi there", Body: "a&b=c"},
+			"mailto:john@example.com?subject=Hi%20there&body=a%26b%3Dc"},
+		{"Phone", Phone{Number: "+1 (555) 123-4567"}, "TEL:+15551234567"},
+		{"SMS", SMS{Number: "+15551234567"}, "SMS:+15551234567"},
+		{"SMS with message", SMS{Number: "+15551234567", Message: "Olá!"},
+			"sms:+15551234567?body=Ol%C3%A1%21"},
+		{"Geo", Geo{Latitude: 37.786971, Longitude: -122.399677}, "geo:37.786971,-122.399677"},
+		{"Geo with altitude", Geo{Latitude: 1.5, Longitude: 2, Altitude: 30}, "geo:1.5,2,30"},
+		{"WhatsApp", WhatsApp{Number: "+52 1 55 1234 5678"}, "HTTPS://WA.ME/5215512345678"},
+		{"WhatsApp with message", WhatsApp{Number: "15551234567", Message: "Hello world"},
+			"https://wa.me/15551234567?text=Hello%20world"},
+		{"Bitcoin bech32", Bitcoin{Address: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"},
+			"BITCOIN:BC1QAR0SRRR7XFKVY5L643LYDNW9RE59GTZZWF5MDQ"},
+		{"Bitcoin with amount", Bitcoin{Address: "BC1QAR0SRRR7XFKVY5L643LYDNW9RE59GTZZWF5MDQ", Amount: 150000000, Label: "Shop"},
+			"bitcoin:bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq?amount=1.5&label=Shop"},
+		{"Bitcoin base58", Bitcoin{Address: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", Amount: 1},
+			"bitcoin:1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2?amount=0.00000001"},
+		{"Ethereum", Ethereum{Address: "0xfb6916095ca1df60bB79Ce92cE3Ea74c37c5d359", ChainID: 1, Value: big.NewInt(2014000000000000000)},
+			"ethereum:0xfb6916095ca1df60bB79Ce92cE3Ea74c37c5d359@1?value=2014000000000000000"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.builder.Build()
+			if err != nil {
+				t.Fatalf("Build failed: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Build() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBuilderValidation(t *testing.T) {
+	testCases := []struct {
+		name    string
+		builder Builder
+	}{
+		{"Email without recipient", Email{}},
+		{"Email without domain", Email{To: "john"}},
+		{"Phone with letters", Phone{Number: "555-CALL-NOW"}},
+		{"Empty phone", Phone{Number: "+"}},
+		{"Latitude out of range", Geo{Latitude: 91}},
+		{"Latitude NaN", Geo{Latitude: math.NaN()}},
+		{"Longitude NaN", Geo{Longitude: math.NaN()}},
+		{"Infinite altitude", Geo{Altitude: math.Inf(1)}},
+		{"Negative bitcoin amount", Bitcoin{Address: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", Amount: -1}},
+		{"Bad ethereum address", Ethereum{Address: "0x1234"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tc.builder.Build(); err == nil {
+				t.Error("Expected validation error, got nil")
+			}
+		})
+	}
+}
+
+// TestUppercaseFormsUseAlphanumericMode checks the point of the uppercase forms:
+// the encoder should pick Alphanumeric mode for them
+func TestUppercaseFormsUseAlphanumericMode(t *testing.T) {
+	builders := []Builder{
+		Phone{Number: "+15551234567"},
+		SMS{Number: "+15551234567"},
+		WhatsApp{Number: "+15551234567"},
+		Bitcoin{Address: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"},
+	}
+
+	for _, b := range builders {
+		data, err := b.Build()
+		if err != nil {
+			t.Fatalf("Build failed: %v", err)
+		}
+
+		qr, err := myqrcode.New(data, myqrcode.Medium)
+		if err != nil {
+			t.Fatalf("Failed to create QR code: %v", err)
+		}
+		if err := qr.Encode(); err != nil {
+			t.Fatalf("Failed to encode QR code: %v", err)
+		}
+
+		if qr.Mode != myqrcode.Alphanumeric {
+			t.Errorf("%q encoded with mode %d, want Alphanumeric", data, qr.Mode)
+		}
+	}
+}
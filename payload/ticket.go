@@ -0,0 +1,134 @@
+package payload
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/juparave/myqrcode"
+)
+
+// TicketPrefix marks base45 ticket payloads, the way "HC1:" marks EU DCC certificates
+const TicketPrefix = "TK1:"
+
+// ticketFormat is the first byte of every ticket blob so the layout can evolve
+const ticketFormat = 0x01
+
+// TicketOptions controls how a ticket is packed into a QR code
+type TicketOptions struct {
+	// Base45 wraps the signed blob in base45 so the symbol uses Alphanumeric
+	// mode. Otherwise the raw blob is stored in Byte mode.
+	Base45 bool
+
+	// Level is the error correction level the version is sized for
+	Level myqrcode.ErrorCorrectionLevel
+}
+
+// Ticket is a compressed, signed payload ready to be put in a QR code
+type Ticket struct {
+	Data    []byte // Bytes to encode, including TicketPrefix when base45-wrapped
+	Mode    myqrcode.EncodingMode
+	Level   myqrcode.ErrorCorrectionLevel
+	Version int // Smallest version that holds Data at Level
+}
+
+// EncodeTicket serializes v as JSON, compresses it with deflate and signs it
+// with key. The resulting version is computed up front, so oversized tickets
+// fail here rather than when the QR code is encoded.
+func EncodeTicket(v any, key ed25519.PrivateKey, opts TicketOptions) (*Ticket, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, errors.New("invalid ed25519 private key")
+	}
+
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize ticket: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(ticketFormat)
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	// The signature covers the format byte and the compressed body
+	signed := buf.Bytes()
+	blob := append(signed, ed25519.Sign(key, signed)...)
+
+	ticket := &Ticket{
+		Data:  blob,
+		Mode:  myqrcode.Byte,
+		Level: opts.Level,
+	}
+	if opts.Base45 {
+		ticket.Data = []byte(TicketPrefix + EncodeBase45(blob))
+		ticket.Mode = myqrcode.Alphanumeric
+	}
+
+	ticket.Version, err = myqrcode.MinimumVersion(len(ticket.Data), ticket.Mode, ticket.Level)
+	if err != nil {
+		return nil, fmt.Errorf("ticket too large: %w", err)
+	}
+
+	return ticket, nil
+}
+
+// QRCode creates an unencoded QR code for the ticket with its mode and version fixed
+func (t *Ticket) QRCode() (*myqrcode.QRCode, error) {
+	qr, err := myqrcode.NewBytes(t.Data, t.Level)
+	if err != nil {
+		return nil, err
+	}
+	qr.Mode = t.Mode
+	qr.Version = t.Version
+	return qr, nil
+}
+
+// DecodeTicket verifies a scanned ticket against pub and unpacks its JSON into v.
+// Both base45-wrapped and raw tickets are accepted.
+func DecodeTicket(scanned []byte, pub ed25519.PublicKey, v any) error {
+	if len(pub) != ed25519.PublicKeySize {
+		return errors.New("invalid ed25519 public key")
+	}
+
+	blob := scanned
+	if text := string(scanned); strings.HasPrefix(text, TicketPrefix) {
+		var err error
+		blob, err = DecodeBase45(strings.TrimPrefix(text, TicketPrefix))
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(blob) < 1+ed25519.SignatureSize {
+		return errors.New("ticket too short")
+	}
+	signed := blob[:len(blob)-ed25519.SignatureSize]
+	signature := blob[len(blob)-ed25519.SignatureSize:]
+
+	if !ed25519.Verify(pub, signed, signature) {
+		return errors.New("invalid ticket signature")
+	}
+	if signed[0] != ticketFormat {
+		return fmt.Errorf("unsupported ticket format %d", signed[0])
+	}
+
+	body, err := io.ReadAll(flate.NewReader(bytes.NewReader(signed[1:])))
+	if err != nil {
+		return fmt.Errorf("failed to decompress ticket: %w", err)
+	}
+
+	return json.Unmarshal(body, v)
+}
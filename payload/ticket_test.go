@@ -0,0 +1,149 @@
+package payload
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/juparave/myqrcode"
+)
+
+type testTicket struct {
+	Event  string   `json:"event"`
+	Holder string   `json:"holder"`
+	Seat   string   `json:"seat"`
+	Perks  []string `json:"perks"`
+}
+
+func TestBase45(t *testing.T) {
+	// Test vectors from RFC 9285
+	vectors := map[string]string{
+		"AB":      "BB8",
+		"Hello!!": "%69 VD92EX0",
+		"base-45": "UJCLQE7W581",
+		"ietf!":   "QED8WEX0",
+	}
+
+	for plain, encoded := range vectors {
+		if got := EncodeBase45([]byte(plain)); got != encoded {
+			t.Errorf("EncodeBase45(%q) = %q, want %q", plain, got, encoded)
+		}
+
+		decoded, err := DecodeBase45(encoded)
+		if err != nil {
+			t.Fatalf("DecodeBase45(%q) failed: %v", encoded, err)
+		}
+		if string(decoded) != plain {
+			t.Errorf("DecodeBase45(%q) = %q, want %q", encoded, decoded, plain)
+		}
+	}
+
+	for _, invalid := range []string{"GGW", "ZZZ", "A", "ab"} {
+		if _, err := DecodeBase45(invalid); err == nil {
+			t.Errorf("DecodeBase45(%q) should fail", invalid)
+		}
+	}
+}
+
+func TestTicketRoundTrip(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	original := testTicket{
+		Event:  "Go Conference 2026",
+		Holder: "Jane Doe",
+		Seat:   "A-12",
+		Perks:  []string{"workshop", "workshop", "workshop", "dinner"},
+	}
+
+	for _, base45 := range []bool{false, true} {
+		ticket, err := EncodeTicket(original, key, TicketOptions{Base45: base45, Level: myqrcode.Medium})
+		if err != nil {
+			t.Fatalf("EncodeTicket failed: %v", err)
+		}
+
+		wantMode := myqrcode.Byte
+		if base45 {
+			wantMode = myqrcode.Alphanumeric
+			if !strings.HasPrefix(string(ticket.Data), TicketPrefix) {
+				t.Errorf("Base45 ticket missing %q prefix", TicketPrefix)
+			}
+		}
+		if ticket.Mode != wantMode {
+			t.Errorf("Ticket mode %s, want %s", ticket.Mode, wantMode)
+		}
+
+		// The reported version must be the one the QR code is encoded with
+		qr, err := ticket.QRCode()
+		if err != nil {
+			t.Fatalf("Failed to create QR code: %v", err)
+		}
+		if err := qr.Encode(); err != nil {
+			t.Fatalf("Failed to encode QR code: %v", err)
+		}
+		if qr.Version != ticket.Version {
+			t.Errorf("Encoded version %d, reported %d", qr.Version, ticket.Version)
+		}
+
+		// Scan it back and verify
+		result, err := myqrcode.DecodeMatrix(qr.Matrix)
+		if err != nil {
+			t.Fatalf("Failed to decode matrix: %v", err)
+		}
+		if !bytes.Equal(result.Data, ticket.Data) {
+			t.Fatal("Scanned data does not match ticket data")
+		}
+
+		var decoded testTicket
+		if err := DecodeTicket(result.Data, pub, &decoded); err != nil {
+			t.Fatalf("DecodeTicket failed: %v", err)
+		}
+		if decoded.Holder != original.Holder || decoded.Seat != original.Seat || len(decoded.Perks) != 4 {
+			t.Errorf("Decoded %+v, want %+v", decoded, original)
+		}
+
+		t.Logf("base45=%v: %d bytes, version %d", base45, len(ticket.Data), ticket.Version)
+	}
+}
+
+func TestTicketVerification(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(nil)
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+	pub := key.Public().(ed25519.PublicKey)
+
+	ticket, err := EncodeTicket(testTicket{Event: "Gala", Holder: "John"}, key, TicketOptions{})
+	if err != nil {
+		t.Fatalf("EncodeTicket failed: %v", err)
+	}
+
+	var decoded testTicket
+	if err := DecodeTicket(ticket.Data, otherPub, &decoded); err == nil {
+		t.Error("Expected signature error with the wrong key")
+	}
+
+	tampered := append([]byte(nil), ticket.Data...)
+	tampered[3] ^= 0x01
+	if err := DecodeTicket(tampered, pub, &decoded); err == nil {
+		t.Error("Expected signature error for tampered ticket")
+	}
+
+	if err := DecodeTicket(ticket.Data[:10], pub, &decoded); err == nil {
+		t.Error("Expected error for truncated ticket")
+	}
+}
+
+func TestTicketTooLarge(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(nil)
+
+	// Random data does not compress
+	noise := make([]byte, 4000)
+	rand.Read(noise)
+
+	if _, err := EncodeTicket(noise, key, TicketOptions{Level: myqrcode.High}); err == nil {
+		t.Error("Expected error for a ticket exceeding version 40")
+	}
+}
@@ -2,7 +2,19 @@ package myqrcode
 
 func placeData(matrix *Matrix, data []byte) {
 	bits := bytesToBits(data)
-	bitIndex := 0
+
+	for i, pos := range dataModulePositions(matrix) {
+		// Remainder bits past the end of the data are left light
+		bit := i < len(bits) && bits[i] == 1
+		matrix.Set(pos[0], pos[1], bit)
+	}
+}
+
+// dataModulePositions returns the free (non-reserved) modules in data placement
+// order: two-column strips from the right edge, alternating upward and downward.
+// Index i holds bit i of the interleaved codeword sequence.
+func dataModulePositions(matrix *Matrix) [][2]int {
+	var positions [][2]int
 
 	size := matrix.Size
 	direction := -1 // Start going up
@@ -20,26 +32,21 @@ func placeData(matrix *Matrix, data []byte) {
 				actualRow = row
 			}
 
-			// Place data in the two columns
+			// Visit the two columns, right one first
 			for c := 0; c < 2; c++ {
 				x := col - c
 				y := actualRow
 
 				if !matrix.IsReserved(x, y) {
-					var bit bool
-					if bitIndex < len(bits) {
-						bit = bits[bitIndex] == 1
-						bitIndex++
-					} else {
-						bit = false
-					}
-					matrix.Set(x, y, bit)
+					positions = append(positions, [2]int{x, y})
 				}
 			}
 		}
 
 		direction *= -1 // Change direction
 	}
+
+	return positions
 }
 
 func applyMask(matrix *Matrix, maskPattern int) *Matrix {
@@ -0,0 +1,154 @@
+package myqrcode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"math"
+)
+
+// PrintUnit is the unit of a PrintSize
+type PrintUnit int
+
+const (
+	Millimeters PrintUnit = iota
+	Inches
+)
+
+func (u PrintUnit) String() string {
+	if u == Inches {
+		return "in"
+	}
+	return "mm"
+}
+
+const mmPerInch = 25.4
+
+// defaultMinXDimension is the smallest module, in millimeters, that common
+// scanners read reliably from paper (about 10 mil)
+const defaultMinXDimension = 0.25
+
+// minModuleDots is the fewest printer dots per module that survive dot gain
+const minModuleDots = 2
+
+// PrintSize describes a code printed at a physical size
+type PrintSize struct {
+	Width         float64 // Side of the symbol with its quiet zone
+	Unit          PrintUnit
+	DPI           int     // Printer resolution, such as 203 or 300
+	MinXDimension float64 // Smallest module the printer and scanners handle, in Unit (0: 0.25 mm)
+}
+
+// PrintLayout is the dot layout of a code at a PrintSize. Modules are whole
+// dots, so the symbol may come out a little smaller than the width allows;
+// the quiet zone takes up the difference and the image keeps its size.
+type PrintLayout struct {
+	ImageSize  int     // Image side in dots
+	ModuleSize int     // Module side in dots
+	QuietZone  float64 // Quiet zone in dots
+	XDimension float64 // Module side in millimeters
+	Warnings   []string
+}
+
+// PrintLayout computes the dot layout for a printed size, with warnings
+// when modules come out smaller than the minimum X-dimension or too few
+// dots wide to print cleanly
+func (qr *QRCode) PrintLayout(p PrintSize) (PrintLayout, error) {
+	if qr.Matrix == nil {
+		return PrintLayout{}, errors.New("QR code not encoded")
+	}
+	if p.Width <= 0 || p.DPI <= 0 {
+		return PrintLayout{}, errors.New("print size needs a positive width and DPI")
+	}
+
+	mm := func(v float64) float64 {
+		if p.Unit == Inches {
+			return v * mmPerInch
+		}
+		return v
+	}
+
+	layout := PrintLayout{ImageSize: int(math.Round(mm(p.Width) / mmPerInch * float64(p.DPI)))}
+	module, quiet, err := qr.imageLayout(StyleConfig{ImageSize: layout.ImageSize, SnapModules: true})
+	if err != nil {
+		return PrintLayout{}, fmt.Errorf("%g%s at %d dpi is too small: %w", p.Width, p.Unit, p.DPI, err)
+	}
+	layout.ModuleSize, layout.QuietZone = int(module), quiet
+	layout.XDimension = module / float64(p.DPI) * mmPerInch
+
+	minX := defaultMinXDimension
+	if p.MinXDimension > 0 {
+		minX = mm(p.MinXDimension)
+	}
+	if layout.XDimension < minX {
+		layout.Warnings = append(layout.Warnings, fmt.Sprintf("modules are %.3f mm, under the %.3f mm minimum X-dimension", layout.XDimension, minX))
+	}
+	if layout.ModuleSize < minModuleDots {
+		layout.Warnings = append(layout.Warnings, fmt.Sprintf("modules are %d dot wide at %d dpi; dot gain can merge them", layout.ModuleSize, p.DPI))
+	}
+
+	return layout, nil
+}
+
+// ToPrintImage renders the code at a physical size: ImageSize and
+// SnapModules are set from the layout, so modules are whole printer dots.
+// A Frame is added outside the printed size. Pass the layout's warnings on
+// to whoever chose the size; write the image with EncodePNG to keep the DPI.
+func (qr *QRCode) ToPrintImage(config StyleConfig, p PrintSize) (image.Image, PrintLayout, error) {
+	layout, err := qr.PrintLayout(p)
+	if err != nil {
+		return nil, PrintLayout{}, err
+	}
+
+	config.ImageSize = layout.ImageSize
+	config.SnapModules = true
+	config.QuietZone = 0
+	img, err := qr.ToImage(config)
+	if err != nil {
+		return nil, PrintLayout{}, err
+	}
+	return img, layout, nil
+}
+
+// EncodePNG writes img as a PNG with a pHYs chunk recording the DPI, so
+// printing and layout software reproduce the physical size. A dpi of zero
+// or less writes a plain PNG.
+func EncodePNG(w io.Writer, img image.Image, dpi int) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	data := buf.Bytes()
+	if dpi <= 0 {
+		_, err := w.Write(data)
+		return err
+	}
+
+	// The signature and IHDR come first: 8 bytes, then a 13-byte IHDR
+	// chunk with its length, type and CRC. pHYs must precede the image data.
+	const headerEnd = 8 + 4 + 4 + 13 + 4
+	if len(data) < headerEnd || string(data[12:16]) != "IHDR" {
+		return errors.New("unexpected PNG layout")
+	}
+
+	ppm := uint32(math.Round(float64(dpi) / mmPerInch * 1000)) // Pixels per meter
+	chunk := make([]byte, 4+4+9+4)
+	binary.BigEndian.PutUint32(chunk[0:], 9)
+	copy(chunk[4:], "pHYs")
+	binary.BigEndian.PutUint32(chunk[8:], ppm)
+	binary.BigEndian.PutUint32(chunk[12:], ppm)
+	chunk[16] = 1 // Unit: meter
+	binary.BigEndian.PutUint32(chunk[17:], crc32.ChecksumIEEE(chunk[4:17]))
+
+	for _, part := range [][]byte{data[:headerEnd], chunk, data[headerEnd:]} {
+		if _, err := w.Write(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
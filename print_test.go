@@ -0,0 +1,123 @@
+package myqrcode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image/png"
+	"os"
+	"testing"
+)
+
+func TestPrintLayout(t *testing.T) {
+	qr, err := New("https://meet.google.com/abc-defg-hij", High)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		size     PrintSize
+		dots     int
+		module   int
+		warnings int
+	}{
+		// 30 mm is 239.8 dots at 203 dpi; 45 modules with the quiet zone fit 5 dots each
+		{"30mm_203dpi", PrintSize{Width: 30, DPI: 203}, 240, 5, 0},
+		{"1in_300dpi", PrintSize{Width: 1, Unit: Inches, DPI: 300}, 300, 6, 0},
+		// 2 dots at 203 dpi are 0.25 mm, just at the minimum
+		{"12mm_203dpi", PrintSize{Width: 12, DPI: 203}, 96, 2, 0},
+		// Single dot modules are too small on both counts
+		{"8mm_203dpi", PrintSize{Width: 8, DPI: 203}, 64, 1, 2},
+		{"custom_minimum", PrintSize{Width: 1, Unit: Inches, DPI: 300, MinXDimension: 0.03}, 300, 6, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout, err := qr.PrintLayout(tt.size)
+			if err != nil {
+				t.Fatalf("Failed to lay out: %v", err)
+			}
+			if layout.ImageSize != tt.dots || layout.ModuleSize != tt.module {
+				t.Errorf("Expected %d dots with %d dot modules, got %d and %d", tt.dots, tt.module, layout.ImageSize, layout.ModuleSize)
+			}
+			if len(layout.Warnings) != tt.warnings {
+				t.Errorf("Expected %d warnings, got %q", tt.warnings, layout.Warnings)
+			}
+			if got := float64(layout.ModuleSize)*float64(qr.Size) + 2*layout.QuietZone; got != float64(layout.ImageSize) {
+				t.Errorf("Expected modules and quiet zone to fill %d dots, got %v", layout.ImageSize, got)
+			}
+		})
+	}
+
+	if _, err := qr.PrintLayout(PrintSize{Width: 4, DPI: 203}); err == nil {
+		t.Error("Expected an error when modules get less than one dot")
+	}
+	if _, err := qr.PrintLayout(PrintSize{Width: 30}); err == nil {
+		t.Error("Expected an error without a DPI")
+	}
+}
+
+func TestToPrintImage(t *testing.T) {
+	qr, err := New("https://meet.google.com/abc-defg-hij", High)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	img, layout, err := qr.ToPrintImage(DefaultStyleConfig(), PrintSize{Width: 30, DPI: 203})
+	if err != nil {
+		t.Fatalf("Failed to generate print image: %v", err)
+	}
+	if img.Bounds().Dx() != layout.ImageSize || img.Bounds().Dy() != layout.ImageSize {
+		t.Errorf("Expected a %d dot image, got %v", layout.ImageSize, img.Bounds())
+	}
+
+	var buf bytes.Buffer
+	if err := EncodePNG(&buf, img, 203); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	data := buf.Bytes()
+
+	if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+		t.Fatalf("Failed to decode PNG: %v", err)
+	}
+
+	// Walk the chunks: every CRC must hold and pHYs must come before IDAT
+	var phys []byte
+	sawData := false
+	for i := 8; i+8 <= len(data); {
+		n := int(binary.BigEndian.Uint32(data[i:]))
+		kind := string(data[i+4 : i+8])
+		body := data[i+8 : i+8+n]
+		if crc := binary.BigEndian.Uint32(data[i+8+n:]); crc != crc32.ChecksumIEEE(data[i+4:i+8+n]) {
+			t.Fatalf("Bad CRC on %s chunk", kind)
+		}
+		switch kind {
+		case "pHYs":
+			if sawData {
+				t.Error("Expected pHYs before the image data")
+			}
+			phys = body
+		case "IDAT":
+			sawData = true
+		}
+		i += 12 + n
+	}
+
+	if phys == nil {
+		t.Fatal("Expected a pHYs chunk")
+	}
+	x, y := binary.BigEndian.Uint32(phys[0:]), binary.BigEndian.Uint32(phys[4:])
+	if x != 7992 || y != 7992 || phys[8] != 1 {
+		t.Errorf("Expected 7992 pixels per meter (203 dpi), got %d x %d, unit %d", x, y, phys[8])
+	}
+
+	os.MkdirAll("test_output", 0755)
+	os.WriteFile("test_output/test_print_203dpi.png", data, 0644)
+}
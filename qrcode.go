@@ -2,6 +2,7 @@ package myqrcode
 
 import (
 	"errors"
+	"fmt"
 	"image"
 	"image/color"
 )
@@ -18,11 +19,26 @@ const (
 type EncodingMode int
 
 const (
-	Numeric EncodingMode = iota
+	Auto EncodingMode = iota // Detect the most compact mode from the data
+	Numeric
 	Alphanumeric
 	Byte
 )
 
+func (m EncodingMode) String() string {
+	switch m {
+	case Auto:
+		return "Auto"
+	case Numeric:
+		return "Numeric"
+	case Alphanumeric:
+		return "Alphanumeric"
+	case Byte:
+		return "Byte"
+	}
+	return fmt.Sprintf("EncodingMode(%d)", int(m))
+}
+
 type QRCode struct {
 	Version         int
 	ErrorCorrection ErrorCorrectionLevel
@@ -32,6 +48,31 @@ type QRCode struct {
 	Size            int
 	Logo            image.Image
 	LogoSize        int
+	LogoStyle       *LogoStyle // Clip shape, plate and border (nil: the logo as is)
+
+	// LogoMargin is the light margin kept around the logo's silhouette, in
+	// modules (0: half a module, negative: none)
+	LogoMargin float64
+
+	// LogoSpare is how many correctable codewords every block keeps in
+	// reserve after the logo's damage, as a margin for print and scan errors
+	LogoSpare int
+
+	// LogoMode chooses between clearing the modules under the logo and
+	// blending the logo over them
+	LogoMode    LogoMode
+	LogoOpacity float64 // LogoBlend: between 0 and 1, exclusive; otherwise 0 or 1 is opaque
+
+	// LogoAnchor places the logo; LogoX and LogoY are its top-left module
+	// with AnchorCustom
+	LogoAnchor   LogoAnchor
+	LogoX, LogoY int
+
+	// Overlays are extra images, such as corner badges, sharing the logo's
+	// error budget
+	Overlays []Overlay
+
+	cleared [][]bool // Modules Encode cleared for logos and overlays
 }
 
 type StyleConfig struct {
@@ -41,7 +82,76 @@ type StyleConfig struct {
 	CircularDots    bool
 	BackgroundColor color.Color
 	ForegroundColor color.Color
-	ModuleDrawer    ModuleDrawer // New: pluggable module drawing system
+	ModuleDrawer    ModuleDrawer  // New: pluggable module drawing system
+	FinderDrawer    FinderDrawer  // Draws the three finder patterns as whole shapes (nil: per-module squares)
+	Quality         RenderQuality // Anti-aliasing of curved edges: modules, finders, logo plates and frames (default: exact coverage)
+
+	// RoleColors overrides ForegroundColor for modules with a given role,
+	// e.g. a brand color for RoleFinderRing and an accent for RoleFinderCenter.
+	// Custom drawers must implement ColoredModuleDrawer to receive the role
+	// colors; others draw every module in their own color.
+	RoleColors map[ModuleRole]color.Color
+
+	// Fill replaces ForegroundColor with a gradient or texture spanning the
+	// whole symbol. Modules with a RoleColors entry keep their flat color.
+	Fill Fill
+
+	// Background places a picture under the symbol, optionally behind a
+	// light plate. ToImage then checks the contrast of every module.
+	Background *BackgroundImage
+
+	// Frame adds a border and caption around the finished image
+	Frame *Frame
+
+	// ImageSize renders the code exactly this many pixels square, before any
+	// Frame, overriding ModuleSize with a fractional module size. QuietZone
+	// stays in pixels; unset, it is four modules. SnapModules rounds the
+	// module size down to whole pixels for pixel-perfect print and widens
+	// the quiet zone to keep the size.
+	ImageSize   int
+	SnapModules bool
+
+	// Inverted renders light modules on a dark background (inverted
+	// reflectance) by swapping ForegroundColor and BackgroundColor for the
+	// whole image, quiet zone included
+	Inverted bool
+}
+
+// colorFor returns the foreground color used for modules with the given role
+func (c StyleConfig) colorFor(role ModuleRole) color.Color {
+	if col, ok := c.RoleColors[role]; ok && col != nil {
+		return col
+	}
+	return c.ForegroundColor
+}
+
+// foregroundColors returns every distinct foreground color in use,
+// ForegroundColor first and then role colors in role order
+func (c StyleConfig) foregroundColors() []color.Color {
+	colors := []color.Color{c.ForegroundColor}
+	for role := RoleData; role <= RoleVersionInfo; role++ {
+		col := c.colorFor(role)
+		seen := false
+		for _, existing := range colors {
+			if sameRGBA(existing, col) {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			colors = append(colors, col)
+		}
+	}
+	return colors
+}
+
+// sameRGBA reports whether two colors are equal once converted to
+// RGBA64, so equal colors of different types match
+func sameRGBA(a, b color.Color) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return color.RGBA64Model.Convert(a) == color.RGBA64Model.Convert(b)
 }
 
 func New(data string, level ErrorCorrectionLevel) (*QRCode, error) {
@@ -58,29 +168,74 @@ func New(data string, level ErrorCorrectionLevel) (*QRCode, error) {
 	return qr, nil
 }
 
+// NewBytes creates a QR code for raw binary data such as compressed or signed
+// payloads. The data is always encoded in Byte mode, so binary that happens to
+// look like digits or uppercase text is never re-interpreted.
+func NewBytes(data []byte, level ErrorCorrectionLevel) (*QRCode, error) {
+	if len(data) == 0 {
+		return nil, errors.New("data cannot be empty")
+	}
+
+	qr := &QRCode{
+		Data:            string(data),
+		ErrorCorrection: level,
+		Mode:            Byte,
+		LogoSize:        0,
+	}
+
+	return qr, nil
+}
+
 func (qr *QRCode) SetLogo(logo image.Image, size int) {
 	qr.Logo = logo
 	qr.LogoSize = size
 }
 
+// SetLogoBlend keeps the modules under the logo and blends the logo over
+// them at the given opacity, which must lie between 0 and 1 exclusive;
+// Encode rejects any other
+func (qr *QRCode) SetLogoBlend(opacity float64) {
+	qr.LogoMode = LogoBlend
+	qr.LogoOpacity = opacity
+}
+
+// SetLogoStyle clips the logo to a shape and adds a plate and border behind it
+func (qr *QRCode) SetLogoStyle(style LogoStyle) {
+	qr.LogoStyle = &style
+}
+
 func (qr *QRCode) Encode() error {
-	// Detect encoding mode if not set
-	if qr.Mode == 0 {
+	// Detect encoding mode unless the caller forced one
+	if qr.Mode == Auto {
 		qr.Mode = detectMode(qr.Data)
+	} else if err := validateMode(qr.Data, qr.Mode); err != nil {
+		return err
 	}
 
 	// Determine version based on data length
-	if qr.Version == 0 {
-		qr.Version = determineVersion(qr.Data, qr.Mode, qr.ErrorCorrection)
+	fixedVersion := qr.Version != 0
+	if !fixedVersion {
+		version, err := MinimumVersion(len(qr.Data), qr.Mode, qr.ErrorCorrection)
+		if err != nil {
+			return err
+		}
+		qr.Version = version
 	}
 
-	// Adjust error correction level if logo is present
-	if qr.Logo != nil && qr.LogoSize > 0 {
-		versionInfo := getVersionInfo(qr.Version)
-		qr.Size = versionInfo.Size
-
-		placement := optimizeLogoPlacement(&Matrix{Size: qr.Size}, qr.LogoSize, qr.Version)
-		qr.ErrorCorrection = adjustErrorCorrectionForLogo(qr.ErrorCorrection, placement, qr.Version)
+	// With a logo, grow the version or error correction level until every
+	// block can correct the codewords the logo covers
+	var excavation [][]bool
+	for _, o := range qr.overlays() {
+		if err := o.validate(); err != nil {
+			return err
+		}
+	}
+	if len(qr.overlays()) > 0 {
+		version, level, mask, err := qr.planLogo(fixedVersion)
+		if err != nil {
+			return err
+		}
+		qr.Version, qr.ErrorCorrection, excavation = version, level, mask
 	}
 
 	// Get version info
@@ -99,17 +254,8 @@ func (qr *QRCode) Encode() error {
 	// Add error correction
 	finalData := addErrorCorrection(encodedData, qr.Version, qr.ErrorCorrection)
 
-	// Create matrix and add patterns
-	matrix := NewMatrix(qr.Size)
-	matrix.AddFinderPatterns()
-	matrix.AddTimingPatterns()
-	matrix.AddDarkModule()
-
-	// Reserve logo area if present
-	if qr.Logo != nil && qr.LogoSize > 0 {
-		placement := optimizeLogoPlacement(matrix, qr.LogoSize, qr.Version)
-		reserveLogoArea(matrix, placement)
-	}
+	// Create matrix and add function patterns
+	matrix := newSymbolMatrix(qr.Version)
 
 	// Place data
 	placeData(matrix, finalData)
@@ -117,6 +263,14 @@ func (qr *QRCode) Encode() error {
 	// Select best mask
 	finalMatrix, _ := selectBestMask(matrix, qr.ErrorCorrection)
 
+	// Clear the logo area. Data is placed underneath it as usual so scanners
+	// stay aligned with the codeword layout; error correction recovers the
+	// covered codewords.
+	qr.cleared = excavation
+	if excavation != nil {
+		clearLogoArea(finalMatrix, excavation)
+	}
+
 	// Convert to bool matrix
 	qr.Matrix = make([][]bool, qr.Size)
 	for i := range qr.Matrix {
@@ -194,6 +348,21 @@ func ChromeGappedStyleConfigWithRatio(ratio float64) StyleConfig {
 	}
 }
 
+// OTPStyleConfig returns a plain, high-contrast style for authenticator enrollment codes.
+// It uses square modules and a full four-module quiet zone so the code scans
+// reliably even when displayed small.
+func OTPStyleConfig() StyleConfig {
+	return StyleConfig{
+		ModuleSize:      8,
+		QuietZone:       4 * 8,
+		RoundedCorners:  false,
+		CircularDots:    false,
+		BackgroundColor: color.RGBA{255, 255, 255, 255}, // White
+		ForegroundColor: color.RGBA{0, 0, 0, 255},       // Black
+		ModuleDrawer:    NewSquareModuleDrawer(),
+	}
+}
+
 // Make is a convenience function for one-line QR code generation
 func Make(data string, options ...func(*StyleConfig)) (image.Image, error) {
 	qr, err := New(data, High)
@@ -214,6 +383,49 @@ func Make(data string, options ...func(*StyleConfig)) (image.Image, error) {
 	return qr.ToImage(config)
 }
 
+// MakeOTP generates an authenticator enrollment QR code (e.g. an otpauth:// URI).
+// Logos are never embedded, and Low or Medium error correction is used instead
+// of High: OTP URIs are short and displayed small, so fewer and larger modules
+// scan more robustly than extra redundancy.
+func MakeOTP(data string, options ...func(*StyleConfig)) (image.Image, error) {
+	qr, err := newOTP(data)
+	if err != nil {
+		return nil, err
+	}
+
+	config := OTPStyleConfig()
+	for _, opt := range options {
+		opt(&config)
+	}
+
+	return qr.ToImage(config)
+}
+
+// newOTP encodes data at Medium error correction, or at Low when that
+// saves a version
+func newOTP(data string) (*QRCode, error) {
+	mode := detectMode(data)
+	low, err := MinimumVersion(len(data), mode, Low)
+	if err != nil {
+		return nil, err
+	}
+
+	// Low may also hold data that Medium can't
+	level := Medium
+	if medium, err := MinimumVersion(len(data), mode, Medium); err != nil || low < medium {
+		level = Low
+	}
+
+	qr, err := New(data, level)
+	if err != nil {
+		return nil, err
+	}
+	if err := qr.Encode(); err != nil {
+		return nil, err
+	}
+	return qr, nil
+}
+
 // WithModuleDrawer sets a custom module drawer
 func WithModuleDrawer(drawer ModuleDrawer) func(*StyleConfig) {
 	return func(config *StyleConfig) {
@@ -252,6 +464,87 @@ func WithGappedSquares(sizeRatio float64) func(*StyleConfig) {
 	}
 }
 
+// WithHorizontalBars joins horizontal runs of modules into pill-shaped bars
+func WithHorizontalBars(ratio float64) func(*StyleConfig) {
+	return func(config *StyleConfig) {
+		config.ModuleDrawer = NewHorizontalBarsModuleDrawer(ratio)
+	}
+}
+
+// WithVerticalBars joins vertical runs of modules into pill-shaped bars
+func WithVerticalBars(ratio float64) func(*StyleConfig) {
+	return func(config *StyleConfig) {
+		config.ModuleDrawer = NewVerticalBarsModuleDrawer(ratio)
+	}
+}
+
+// WithLiquid merges neighboring modules into blobs with rounded outer and filleted inner corners
+func WithLiquid(ratio float64) func(*StyleConfig) {
+	return func(config *StyleConfig) {
+		config.ModuleDrawer = NewLiquidModuleDrawer(ratio)
+	}
+}
+
+// WithDiamonds configures diamond-shaped modules
+func WithDiamonds(ratio float64) func(*StyleConfig) {
+	return func(config *StyleConfig) {
+		config.ModuleDrawer = NewDiamondModuleDrawer(ratio)
+	}
+}
+
+// WithStars configures star-shaped modules
+func WithStars(ratio float64) func(*StyleConfig) {
+	return func(config *StyleConfig) {
+		config.ModuleDrawer = NewStarModuleDrawer(ratio)
+	}
+}
+
+// WithDrawerFunc draws every dark module with a callback
+func WithDrawerFunc(fn DrawerFunc) func(*StyleConfig) {
+	return func(config *StyleConfig) {
+		config.ModuleDrawer = NewFuncModuleDrawer(fn)
+	}
+}
+
+// WithHalftone blends a dithered picture into the modules, three subpixels
+// per module side. Larger module sizes give the picture more detail.
+func WithHalftone(picture image.Image) func(*StyleConfig) {
+	return func(config *StyleConfig) {
+		config.ModuleDrawer = NewHalftoneModuleDrawer(picture, 3)
+	}
+}
+
+// WithFinderDrawer sets a custom finder pattern drawer
+func WithFinderDrawer(drawer FinderDrawer) func(*StyleConfig) {
+	return func(config *StyleConfig) {
+		config.FinderDrawer = drawer
+	}
+}
+
+// WithEyes draws finder patterns with the given outer ring and inner ball shapes
+func WithEyes(outer, inner EyeShape) func(*StyleConfig) {
+	return func(config *StyleConfig) {
+		config.FinderDrawer = NewFinderDrawer(outer, inner)
+	}
+}
+
+// WithRoleColor paints modules with the given role in their own color
+func WithRoleColor(role ModuleRole, c color.Color) func(*StyleConfig) {
+	return func(config *StyleConfig) {
+		if config.RoleColors == nil {
+			config.RoleColors = make(map[ModuleRole]color.Color)
+		}
+		config.RoleColors[role] = c
+	}
+}
+
+// WithFill paints the foreground modules with a gradient or texture
+func WithFill(fill Fill) func(*StyleConfig) {
+	return func(config *StyleConfig) {
+		config.Fill = fill
+	}
+}
+
 // WithColors sets foreground and background colors
 func WithColors(fg, bg color.Color) func(*StyleConfig) {
 	return func(config *StyleConfig) {
@@ -260,6 +553,51 @@ func WithColors(fg, bg color.Color) func(*StyleConfig) {
 	}
 }
 
+// WithTransparentBackground leaves everything but the modules transparent,
+// for placing the code over photos or colored pages
+func WithTransparentBackground() func(*StyleConfig) {
+	return func(config *StyleConfig) {
+		config.BackgroundColor = color.Transparent
+	}
+}
+
+// WithBackgroundImage draws a picture under the symbol
+func WithBackgroundImage(img image.Image, scaling BackgroundScaling) func(*StyleConfig) {
+	return func(config *StyleConfig) {
+		if config.Background == nil {
+			config.Background = &BackgroundImage{}
+		}
+		config.Background.Image = img
+		config.Background.Scaling = scaling
+	}
+}
+
+// WithBackgroundPlate puts a light plate between the background image and
+// the modules; a nil color uses 80% opaque white
+func WithBackgroundPlate(mode PlateMode, c color.Color) func(*StyleConfig) {
+	return func(config *StyleConfig) {
+		if config.Background == nil {
+			config.Background = &BackgroundImage{}
+		}
+		config.Background.Plate = mode
+		config.Background.PlateColor = c
+	}
+}
+
+// WithFrame surrounds the code with a border shape and a caption
+func WithFrame(shape FrameShape, caption string) func(*StyleConfig) {
+	return func(config *StyleConfig) {
+		config.Frame = &Frame{Shape: shape, Caption: caption}
+	}
+}
+
+// WithInverted renders light modules on a dark background
+func WithInverted() func(*StyleConfig) {
+	return func(config *StyleConfig) {
+		config.Inverted = true
+	}
+}
+
 // WithModuleSize sets the module size
 func WithModuleSize(size int) func(*StyleConfig) {
 	return func(config *StyleConfig) {
@@ -6,6 +6,7 @@ import (
 	"image/color"
 	"image/png"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -175,6 +176,141 @@ func TestLogoSizeAdjustment(t *testing.T) {
 	}
 }
 
+func TestForcedEncodingMode(t *testing.T) {
+	testCases := []struct {
+		name    string
+		data    string
+		mode    EncodingMode
+		wantErr bool
+	}{
+		{"Forced Numeric", "0123456789", Numeric, false},
+		{"Forced Byte on digits", "0123456789", Byte, false},
+		{"Forced Alphanumeric", "HELLO WORLD", Alphanumeric, false},
+		{"Lowercase in Alphanumeric", "hello world", Alphanumeric, true},
+		{"Letters in Numeric", "123ABC", Numeric, true},
+		{"Unknown mode", "HELLO", EncodingMode(42), true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			qr, err := New(tc.data, Medium)
+			if err != nil {
+				t.Fatalf("Failed to create QR code: %v", err)
+			}
+			qr.Mode = tc.mode
+
+			err = qr.Encode()
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("Expected error forcing %s mode on %q", tc.mode, tc.data)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to encode QR code: %v", err)
+			}
+			if qr.Mode != tc.mode {
+				t.Errorf("Expected mode %s to be kept, got %s", tc.mode, qr.Mode)
+			}
+		})
+	}
+}
+
+func TestAutoModeDetection(t *testing.T) {
+	testCases := []struct {
+		data string
+		want EncodingMode
+	}{
+		{"0123456789", Numeric},
+		{"HELLO WORLD", Alphanumeric},
+		{"hello world", Byte},
+	}
+
+	for _, tc := range testCases {
+		qr, err := New(tc.data, Medium)
+		if err != nil {
+			t.Fatalf("Failed to create QR code: %v", err)
+		}
+		if qr.Mode != Auto {
+			t.Fatalf("Expected new QR code to default to Auto, got %s", qr.Mode)
+		}
+
+		err = qr.Encode()
+		if err != nil {
+			t.Fatalf("Failed to encode QR code: %v", err)
+		}
+		if qr.Mode != tc.want {
+			t.Errorf("%q: detected %s, want %s", tc.data, qr.Mode, tc.want)
+		}
+	}
+}
+
+func TestDataTooLarge(t *testing.T) {
+	data := strings.Repeat("x", DataCapacity(40, Byte, High)+1)
+
+	qr, err := New(data, High)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err == nil {
+		t.Error("Expected error for data exceeding version 40 capacity")
+	}
+
+	version, err := MinimumVersion(DataCapacity(10, Alphanumeric, Quartile), Alphanumeric, Quartile)
+	if err != nil || version != 10 {
+		t.Errorf("MinimumVersion = %d, %v; want 10", version, err)
+	}
+}
+
+func TestMakeOTP(t *testing.T) {
+	data := "otpauth://totp/ACME:john?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&issuer=ACME"
+
+	img, err := MakeOTP(data)
+	if err != nil {
+		t.Fatalf("Failed to generate OTP QR code: %v", err)
+	}
+
+	// Corner pixel must be quiet zone background
+	r, g, b, _ := img.At(0, 0).RGBA()
+	if r != 0xffff || g != 0xffff || b != 0xffff {
+		t.Errorf("Expected white quiet zone, got (%d, %d, %d)", r, g, b)
+	}
+
+	saveTestImage(t, img, "test_otp.png")
+}
+
+func TestMakeOTPLevel(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		level ErrorCorrectionLevel
+	}{
+		// 40 bytes fit version 3 at both Low and Medium
+		{"medium", "otpauth://totp/ACME:jo?secret=GEZDGNBVGY", Medium},
+		// 76 bytes need version 5 at Medium but only version 4 at Low
+		{"low", "otpauth://totp/ACME:john?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&issuer=ACME", Low},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qr, err := newOTP(tt.data)
+			if err != nil {
+				t.Fatalf("Failed to encode OTP QR code: %v", err)
+			}
+			if qr.ErrorCorrection != tt.level {
+				t.Errorf("Expected error correction %v, got %v", tt.level, qr.ErrorCorrection)
+			}
+			if qr.Logo != nil || len(qr.overlays()) != 0 {
+				t.Error("Expected no logo in an OTP QR code")
+			}
+		})
+	}
+
+	if _, err := newOTP(strings.Repeat("x", 3000)); err == nil {
+		t.Error("Expected an error for data beyond version 40")
+	}
+}
+
 // Helper functions for testing
 
 func saveTestImage(t *testing.T, img image.Image, filename string) {
@@ -0,0 +1,183 @@
+package myqrcode
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestSpriteQuality(t *testing.T) {
+	const size = 8
+	r := float32(size) / 2
+	area := math.Pi * float64(r*r)
+
+	for _, tc := range []struct {
+		name      string
+		quality   RenderQuality
+		tolerance float64 // Allowed error in covered pixels
+	}{
+		{"exact", QualityExact, 0.5},
+		{"supersampled", QualitySupersampled, 1.5},
+		{"aliased", QualityAliased, 4},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sprite := renderSprite(size, color.Black, tc.quality, circle(r, r, r))
+
+			covered := 0.0
+			for y := 0; y < size; y++ {
+				for x := 0; x < size; x++ {
+					a := sprite.RGBAAt(x, y).A
+					covered += float64(a) / 255
+
+					// A circle is symmetric in both axes and the diagonal
+					for _, m := range [][2]int{{size - 1 - x, y}, {x, size - 1 - y}, {y, x}} {
+						if b := sprite.RGBAAt(m[0], m[1]).A; absDiff(a, b) > 1 {
+							t.Fatalf("Pixel (%d, %d) is %d but its mirror (%d, %d) is %d", x, y, a, m[0], m[1], b)
+						}
+					}
+					if tc.quality == QualityAliased && a != 0 && a != 255 {
+						t.Fatalf("Expected only full or empty pixels, got %d at (%d, %d)", a, x, y)
+					}
+				}
+			}
+
+			if math.Abs(covered-area) > tc.tolerance {
+				t.Errorf("Expected about %.2f covered pixels, got %.2f", area, covered)
+			}
+		})
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	src := image.NewAlpha(image.Rect(0, 0, 8, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 8; x++ {
+			if x < 3 {
+				src.SetAlpha(x, y, color.Alpha{255})
+			}
+		}
+	}
+
+	dst := downsample(src, 4)
+	if dst.Bounds() != image.Rect(0, 0, 2, 1) {
+		t.Fatalf("Expected a 2x1 result, got %v", dst.Bounds())
+	}
+	if a := dst.AlphaAt(0, 0).A; a != 191 {
+		t.Errorf("Expected three quarters coverage, got %d", a)
+	}
+	if a := dst.AlphaAt(1, 0).A; a != 0 {
+		t.Errorf("Expected no coverage, got %d", a)
+	}
+}
+
+func TestRenderQualityReadability(t *testing.T) {
+	qr, err := New("https://meet.google.com/abc-defg-hij", Medium)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	const ms, qz = 5, 20
+	for name, quality := range map[string]RenderQuality{
+		"exact":        QualityExact,
+		"supersampled": QualitySupersampled,
+		"aliased":      QualityAliased,
+	} {
+		t.Run(name, func(t *testing.T) {
+			config := DefaultStyleConfig()
+			config.ModuleSize = ms
+			config.QuietZone = qz
+			config.ModuleDrawer = NewCircleModuleDrawer()
+			config.Quality = quality
+
+			img, err := qr.ToImage(config)
+			if err != nil {
+				t.Fatalf("Failed to generate image: %v", err)
+			}
+
+			for y := 0; y < qr.Size; y++ {
+				for x := 0; x < qr.Size; x++ {
+					if isFinderPattern(x, y, qr.Size) {
+						continue
+					}
+					if isDark(img, qz+x*ms+ms/2, qz+y*ms+ms/2) != qr.Matrix[y][x] {
+						t.Fatalf("Module (%d, %d) center does not match its bit", x, y)
+					}
+				}
+			}
+
+			saveTestImage(t, img, "test_quality_"+name+".png")
+		})
+	}
+}
+
+func TestAliasedDrawers(t *testing.T) {
+	qr, err := New("https://meet.google.com/abc-defg-hij", Medium)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	picture := image.NewGray(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			picture.SetGray(x, y, color.Gray{uint8(x * 4)})
+		}
+	}
+	drawers := map[string]ModuleDrawer{
+		"square":          NewSquareModuleDrawer(),
+		"circle":          NewCircleModuleDrawer(),
+		"gapped square":   NewGappedSquareModuleDrawer(0.7),
+		"gapped circle":   NewGappedCircleModuleDrawer(0.7),
+		"rounded":         NewRoundedModuleDrawer(0.6),
+		"horizontal bars": NewHorizontalBarsModuleDrawer(0.7),
+		"vertical bars":   NewVerticalBarsModuleDrawer(0.7),
+		"liquid":          NewLiquidModuleDrawer(1),
+		"diamond":         NewDiamondModuleDrawer(1),
+		"star":            NewStarModuleDrawer(1),
+		"halftone":        NewHalftoneModuleDrawer(picture, 3),
+		"func": NewFuncModuleDrawer(func(c *Canvas, ctx ModuleContext) {
+			c.Circle(0.5, 0.5, 0.45)
+		}),
+	}
+	finders := map[string]*ShapeFinderDrawer{
+		"square modules": nil,
+		"rounded":        NewFinderDrawer(EyeRounded, EyeRounded),
+		"circle":         NewFinderDrawer(EyeCircle, EyeCircle),
+		"leaf":           NewFinderDrawer(EyeLeaf, EyeLeaf),
+		"dots":           NewFinderDrawer(EyeDots, EyeDots),
+	}
+
+	for name, drawer := range drawers {
+		for finderName, finder := range finders {
+			config := DefaultStyleConfig()
+			config.ModuleSize = 7
+			config.QuietZone = 14
+			config.ModuleDrawer = drawer
+			if finder != nil {
+				config.FinderDrawer = finder
+			}
+			config.Quality = QualityAliased
+
+			img, err := qr.ToImage(config)
+			if err != nil {
+				t.Fatalf("%s: failed to generate image: %v", name, err)
+			}
+			b := img.Bounds()
+		pixels:
+			for y := b.Min.Y; y < b.Max.Y; y++ {
+				for x := b.Min.X; x < b.Max.X; x++ {
+					if c := img.At(x, y); !sameColor(c, color.Black) && !sameColor(c, color.White) {
+						t.Errorf("%s with %s finders: expected only black and white pixels, got %v at (%d, %d)", name, finderName, c, x, y)
+						break pixels
+					}
+				}
+			}
+		}
+	}
+}
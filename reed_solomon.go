@@ -1,6 +1,8 @@
 package myqrcode
 
 import (
+	"errors"
+
 	"rsc.io/qr/gf256"
 )
 
@@ -89,6 +91,117 @@ func generateErrorCorrection(data []byte, ecCodewords int) []byte {
 	return result
 }
 
+// correctErrors fixes up to ecCodewords/2 corrupted codewords of a block in place
+// and returns how many were corrected. It uses Berlekamp-Massey to find the error
+// locator, a Chien search for the positions and Forney's formula for the values.
+func correctErrors(block []byte, ecCodewords int) (int, error) {
+	field := gf256.NewField(0x11d, 2)
+	n := len(block)
+
+	// Syndromes S_i = r(α^i), matching the generator roots α^0..α^(ec-1)
+	syndromes := make([]byte, ecCodewords)
+	clean := true
+	for i := 0; i < ecCodewords; i++ {
+		x := field.Exp(i)
+		var s byte
+		for _, c := range block {
+			s = field.Mul(s, x) ^ c
+		}
+		syndromes[i] = s
+		if s != 0 {
+			clean = false
+		}
+	}
+	if clean {
+		return 0, nil
+	}
+
+	// Berlekamp-Massey: error locator Λ(x), lowest degree first
+	locator := []byte{1}
+	prev := []byte{1}
+	degree := 0
+	shift := 1
+	prevDiscrepancy := byte(1)
+
+	for k := 0; k < ecCodewords; k++ {
+		discrepancy := syndromes[k]
+		for i := 1; i <= degree && i < len(locator); i++ {
+			discrepancy ^= field.Mul(locator[i], syndromes[k-i])
+		}
+		if discrepancy == 0 {
+			shift++
+			continue
+		}
+
+		coef := field.Mul(discrepancy, field.Inv(prevDiscrepancy))
+		next := make([]byte, max(len(locator), len(prev)+shift))
+		copy(next, locator)
+		for i, p := range prev {
+			next[i+shift] ^= field.Mul(coef, p)
+		}
+
+		if 2*degree <= k {
+			degree = k + 1 - degree
+			prev = locator
+			prevDiscrepancy = discrepancy
+			shift = 1
+		} else {
+			shift++
+		}
+		locator = next
+	}
+
+	if 2*degree > ecCodewords {
+		return 0, errors.New("too many errors to correct")
+	}
+
+	evaluate := func(poly []byte, x byte) byte {
+		var result byte
+		for i := len(poly) - 1; i >= 0; i-- {
+			result = field.Mul(result, x) ^ poly[i]
+		}
+		return result
+	}
+
+	// Chien search: an error at coefficient j of r(x) is a root α^-j of Λ(x)
+	var positions []int
+	for j := 0; j < n; j++ {
+		if evaluate(locator, field.Exp(255-j)) == 0 {
+			positions = append(positions, j)
+		}
+	}
+	if len(positions) != degree {
+		return 0, errors.New("too many errors to correct")
+	}
+
+	// Error evaluator Ω(x) = S(x)Λ(x) mod x^ec
+	evaluator := make([]byte, ecCodewords)
+	for i := range evaluator {
+		for j := 0; j <= i && j < len(locator); j++ {
+			evaluator[i] ^= field.Mul(locator[j], syndromes[i-j])
+		}
+	}
+
+	// Formal derivative Λ'(x): only odd powers survive in characteristic 2
+	derivative := make([]byte, len(locator))
+	for i := 1; i < len(locator); i += 2 {
+		derivative[i-1] = locator[i]
+	}
+
+	// Forney: e_j = X_j * Ω(X_j^-1) / Λ'(X_j^-1)
+	for _, j := range positions {
+		xInv := field.Exp(255 - j)
+		denominator := evaluate(derivative, xInv)
+		if denominator == 0 {
+			return 0, errors.New("too many errors to correct")
+		}
+		magnitude := field.Mul(field.Exp(j), field.Mul(evaluate(evaluator, xInv), field.Inv(denominator)))
+		block[n-1-j] ^= magnitude
+	}
+
+	return degree, nil
+}
+
 func bitsToBytes(bits []int) []byte {
 	for len(bits)%8 != 0 {
 		bits = append(bits, 0)
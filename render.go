@@ -5,14 +5,15 @@ import (
 	"image"
 	"image/color"
 	"image/draw"
-
-	xdraw "golang.org/x/image/draw"
 )
 
 func (qr *QRCode) ToImage(config StyleConfig) (image.Image, error) {
 	if qr.Matrix == nil {
 		return nil, errors.New("QR code not encoded")
 	}
+	if config.ImageSize > 0 {
+		return qr.toSizedImage(config)
+	}
 
 	// Validate and set defaults
 	moduleSize := config.ModuleSize
@@ -33,6 +34,19 @@ func (qr *QRCode) ToImage(config StyleConfig) (image.Image, error) {
 		config.ForegroundColor = color.RGBA{0, 0, 0, 255}
 	}
 
+	// Dark modules take the light color and everything else the dark one;
+	// every drawer paints with these, so the swap is consistent
+	if config.Inverted {
+		config.ForegroundColor, config.BackgroundColor = config.BackgroundColor, config.ForegroundColor
+	}
+
+	// Refuse fills a scanner could not tell from the background
+	if config.Fill != nil {
+		if err := checkFillContrast(config.Fill, config.BackgroundColor); err != nil {
+			return nil, err
+		}
+	}
+
 	// Create two drawers: one for finder patterns, one for data modules
 	squareDrawer := NewSquareModuleDrawer()
 	dataDrawer := config.ModuleDrawer
@@ -46,56 +60,226 @@ func (qr *QRCode) ToImage(config StyleConfig) (image.Image, error) {
 		}
 	}
 
+	// Finder patterns are drawn as whole shapes only when a finder drawer is
+	// set; otherwise they are per-module squares as before
+	finderDrawer := config.FinderDrawer
+
 	// Create image
 	imgSize := qr.Size*moduleSize + 2*quietZone
 	img := image.NewRGBA(image.Rect(0, 0, imgSize, imgSize))
 
 	// Fill background
 	draw.Draw(img, img.Bounds(), &image.Uniform{config.BackgroundColor}, image.Point{}, draw.Src)
+	if config.Background != nil {
+		drawBackgroundImage(img, config.Background)
+		qr.drawPlate(img, config.Background, moduleSize, quietZone, config.Inverted)
+	}
+
+	qr.drawSymbol(img, config, squareDrawer, dataDrawer, finderDrawer, moduleSize, quietZone)
+
+	// Repaint the modules in the foreground color with the fill. The same
+	// modules are drawn again black on white to find each pixel's coverage;
+	// modules with their own role color stay out of the mask.
+	if config.Fill != nil {
+		maskConfig := config
+		maskConfig.BackgroundColor = color.White
+		maskConfig.ForegroundColor = color.Black
+		maskConfig.RoleColors = make(map[ModuleRole]color.Color)
+		for role := RoleData; role <= RoleVersionInfo; role++ {
+			if !sameRGBA(config.colorFor(role), config.ForegroundColor) {
+				maskConfig.RoleColors[role] = color.White
+			}
+		}
+
+		mask := image.NewRGBA(img.Bounds())
+		draw.Draw(mask, mask.Bounds(), image.White, image.Point{}, draw.Src)
+		qr.drawSymbol(mask, maskConfig, squareDrawer, dataDrawer, finderDrawer, moduleSize, quietZone)
+
+		symbolSize := qr.Size * moduleSize
+		symbol := image.Rect(quietZone, quietZone, quietZone+symbolSize, quietZone+symbolSize)
+		applyFill(img, mask, config.Fill.Image(symbol), config.ForegroundColor)
+	}
+
+	// A photo behind the symbol can swallow modules anywhere, so measure them all
+	if config.Background != nil {
+		if err := qr.checkModuleContrast(img, moduleSize, quietZone, config.Inverted); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.Inverted {
+		if err := qr.verifyQuietZone(img, config, moduleSize, quietZone); err != nil {
+			return nil, err
+		}
+	}
+
+	// Draw the logo and overlays
+	var placements, blended []LogoPlacement
+	for _, o := range qr.overlays() {
+		placement := o.placement(qr.Version)
+		drawLogo(img, o.spec(), placement, moduleSize, quietZone, config)
+		placements = append(placements, placement)
+		if o.Mode == LogoBlend {
+			blended = append(blended, placement)
+		}
+	}
+
+	// Alignment patterns stay visible on top of the overlays
+	qr.drawAlignmentOver(img, config, dataDrawer, qr.alignmentUnder(placements), moduleSize, quietZone)
+
+	// Blended logos leave the modules in place; check how many now read wrong
+	if len(blended) > 0 {
+		if err := qr.verifyLogoBlend(img, config, blended, moduleSize, quietZone); err != nil {
+			return nil, err
+		}
+	}
 
+	if config.Frame != nil {
+		return applyFrame(img, config.Frame, config, max(0, frameMargin*moduleSize-quietZone))
+	}
+
+	return img, nil
+}
+
+// drawSymbol draws the modules and finder patterns onto img
+func (qr *QRCode) drawSymbol(img *image.RGBA, config StyleConfig, squareDrawer, dataDrawer ModuleDrawer, finderDrawer FinderDrawer, moduleSize, quietZone int) {
 	// Initialize the drawers
 	squareDrawer.Initialize(img, config)
 	dataDrawer.Initialize(img, config)
+	if prepared, ok := dataDrawer.(SymbolAwareDrawer); ok {
+		symbolSize := qr.Size * moduleSize
+		prepared.Prepare(qr, image.Rect(quietZone, quietZone, quietZone+symbolSize, quietZone+symbolSize))
+	}
 
-	// Draw QR modules using the appropriate drawer
-	for y := 0; y < qr.Size; y++ {
-		for x := 0; x < qr.Size; x++ {
-			imgX := quietZone + x*moduleSize
-			imgY := quietZone + y*moduleSize
-
-			// Create box coordinates [x1, y1, x2, y2]
-			box := [4]int{imgX, imgY, imgX + moduleSize, imgY + moduleSize}
-
-			// Choose the drawer based on whether it's a finder pattern
-			var drawer ModuleDrawer
-			if isFinderPattern(x, y, qr.Size) {
-				drawer = squareDrawer
-			} else {
-				drawer = dataDrawer
-			}
+	// Draw QR modules one color at a time, so sprite-based drawers only
+	// rebuild their sprites when the color changes
+	roles := qr.ModuleRoles()
+	for _, fg := range config.foregroundColors() {
+		setDrawerColor(squareDrawer, fg)
+		setDrawerColor(dataDrawer, fg)
 
-			// Get neighbor context if the drawer needs it
-			var neighbors *ActiveWithNeighbors
-			if drawer.NeedsNeighbors() {
-				if IsFinderPattern(y, x, qr.Size) {
-					neighbors = GetFinderPatternNeighbors(qr.Matrix, y, x)
+		for y := 0; y < qr.Size; y++ {
+			for x := 0; x < qr.Size; x++ {
+				if !sameRGBA(config.colorFor(roles[y][x]), fg) {
+					continue
+				}
+
+				imgX := quietZone + x*moduleSize
+				imgY := quietZone + y*moduleSize
+
+				// Create box coordinates [x1, y1, x2, y2]
+				box := [4]int{imgX, imgY, imgX + moduleSize, imgY + moduleSize}
+
+				// Choose the drawer based on whether it's a finder pattern
+				var drawer ModuleDrawer
+				if isFinderPattern(x, y, qr.Size) {
+					if finderDrawer != nil {
+						continue // Drawn as a whole below
+					}
+					drawer = squareDrawer
+					if _, ok := dataDrawer.(PositionedModuleDrawer); ok {
+						drawer = dataDrawer // It sees the finder role itself
+					}
+				} else {
+					drawer = dataDrawer
+				}
+
+				// Get neighbor context if the drawer needs it
+				var neighbors *ActiveWithNeighbors
+				if drawer.NeedsNeighbors() {
+					if IsFinderPattern(y, x, qr.Size) {
+						neighbors = GetFinderPatternNeighbors(qr.Matrix, y, x)
+					} else {
+						neighbors = GetModuleNeighbors(qr.Matrix, y, x)
+					}
+				}
+
+				// Draw the module
+				if positioned, ok := drawer.(PositionedModuleDrawer); ok {
+					positioned.DrawModuleAt(box, x, y, qr.Matrix[y][x], neighbors)
 				} else {
-					neighbors = GetModuleNeighbors(qr.Matrix, y, x)
+					drawer.DrawModule(box, qr.Matrix[y][x], neighbors)
 				}
 			}
+		}
+	}
+
+	// Draw the finder patterns as whole shapes
+	if finderDrawer != nil {
+		finderDrawer.Initialize(img, config)
 
-			// Draw the module
-			drawer.DrawModule(box, qr.Matrix[y][x], neighbors)
+		finderSize := 7 * moduleSize
+		finders := []struct {
+			corner FinderCorner
+			x, y   int
+		}{
+			{FinderTopLeft, 0, 0},
+			{FinderTopRight, qr.Size - 7, 0},
+			{FinderBottomLeft, 0, qr.Size - 7},
+		}
+		for _, f := range finders {
+			imgX := quietZone + f.x*moduleSize
+			imgY := quietZone + f.y*moduleSize
+			finderDrawer.DrawFinder([4]int{imgX, imgY, imgX + finderSize, imgY + finderSize}, f.corner)
 		}
 	}
+}
 
-	// Draw logo if present
-	if qr.Logo != nil && qr.LogoSize > 0 {
-		placement := calculateLogoPlacement(&Matrix{Size: qr.Size}, qr.LogoSize)
-		drawLogo(img, qr.Logo, placement, moduleSize, quietZone)
+// setDrawerColor switches a drawer's foreground color if it supports it
+// drawAlignmentOver draws the dark modules among the given alignment modules
+// again, over whatever the overlays left there. They go through the data
+// drawer in the alignment color or the fill, the way drawSymbol drew them,
+// and the overlay shows between them.
+func (qr *QRCode) drawAlignmentOver(img *image.RGBA, config StyleConfig, drawer ModuleDrawer, modules []image.Point, moduleSize, quietZone int) {
+	if len(modules) == 0 {
+		return
 	}
 
-	return img, nil
+	symbolSize := qr.Size * moduleSize
+	symbol := image.Rect(quietZone, quietZone, quietZone+symbolSize, quietZone+symbolSize)
+
+	// Draw the modules on a clear layer first, so only their shapes are composited
+	layer := image.NewRGBA(img.Bounds())
+	drawer.Initialize(layer, config)
+	if prepared, ok := drawer.(SymbolAwareDrawer); ok {
+		prepared.Prepare(qr, symbol)
+	}
+	setDrawerColor(drawer, config.colorFor(RoleAlignment))
+
+	for _, m := range modules {
+		if !qr.Matrix[m.Y][m.X] {
+			continue
+		}
+		imgX := quietZone + m.X*moduleSize
+		imgY := quietZone + m.Y*moduleSize
+		box := [4]int{imgX, imgY, imgX + moduleSize, imgY + moduleSize}
+
+		var neighbors *ActiveWithNeighbors
+		if drawer.NeedsNeighbors() {
+			neighbors = GetModuleNeighbors(qr.Matrix, m.Y, m.X)
+		}
+		if positioned, ok := drawer.(PositionedModuleDrawer); ok {
+			positioned.DrawModuleAt(box, m.X, m.Y, true, neighbors)
+		} else {
+			drawer.DrawModule(box, true, neighbors)
+		}
+	}
+
+	// Modules in the plain foreground color take the fill, as in drawSymbol
+	var paint, mask image.Image = layer, nil
+	if config.Fill != nil && sameRGBA(config.colorFor(RoleAlignment), config.ForegroundColor) {
+		paint, mask = config.Fill.Image(symbol), layer
+	}
+	for _, m := range modules {
+		rect := image.Rect(0, 0, moduleSize, moduleSize).Add(image.Pt(quietZone+m.X*moduleSize, quietZone+m.Y*moduleSize))
+		draw.DrawMask(img, rect, paint, rect.Min, mask, rect.Min, draw.Over)
+	}
+}
+
+func setDrawerColor(drawer ModuleDrawer, c color.Color) {
+	if colored, ok := drawer.(ColoredModuleDrawer); ok {
+		colored.SetForegroundColor(c)
+	}
 }
 
 func isFinderPattern(x, y, size int) bool {
@@ -117,7 +301,7 @@ func isFinderPattern(x, y, size int) bool {
 func drawCircle(img *image.RGBA, x, y, size int, color color.Color) {
 	//-FIX--: Draw a simple square instead of a circle for better readability
 	rect := image.Rect(x, y, x+size, y+size)
-	draw.Draw(img, rect, &image.Uniform{color}, image.Point{}, draw.Src)
+	draw.Draw(img, rect, &image.Uniform{color}, image.Point{}, draw.Over)
 }
 
 func drawRoundedModule(img *image.RGBA, x, y, size int, color color.Color) {
@@ -194,20 +378,6 @@ func drawRoundedFinderPattern(img *image.RGBA, x, y, moduleSize int, color color
 	} else {
 		// Regular module for inner parts
 		rect := image.Rect(x, y, x+moduleSize, y+moduleSize)
-		draw.Draw(img, rect, &image.Uniform{color}, image.Point{}, draw.Src)
+		draw.Draw(img, rect, &image.Uniform{color}, image.Point{}, draw.Over)
 	}
 }
-
-func drawLogo(img *image.RGBA, logo image.Image, placement LogoPlacement, moduleSize, quietZone int) {
-	logoWidth := placement.Width * moduleSize
-	logoHeight := placement.Height * moduleSize
-
-	logoX := quietZone + placement.X*moduleSize
-	logoY := quietZone + placement.Y*moduleSize
-
-	// Scale logo to fit
-	logoRect := image.Rect(logoX, logoY, logoX+logoWidth, logoY+logoHeight)
-
-	// Use bilinear scaling for better quality
-	xdraw.BiLinear.Scale(img, logoRect, logo, logo.Bounds(), xdraw.Src, nil)
-}
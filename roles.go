@@ -0,0 +1,121 @@
+package myqrcode
+
+import "fmt"
+
+// ModuleRole classifies what a module is used for in the symbol
+type ModuleRole int
+
+const (
+	RoleData         ModuleRole = iota // Data and error correction codewords (including a cleared logo area)
+	RoleFinderRing                     // Outer 7x7 finder ring and the light ring inside it
+	RoleFinderCenter                   // Inner 3x3 ball of a finder pattern
+	RoleSeparator                      // Light border around the finder patterns
+	RoleAlignment                      // 5x5 alignment patterns
+	RoleTiming                         // Alternating timing patterns on row and column 6
+	RoleFormatInfo                     // Format information and the dark module
+	RoleVersionInfo                    // Version information blocks (versions 7 and up)
+)
+
+func (r ModuleRole) String() string {
+	switch r {
+	case RoleData:
+		return "Data"
+	case RoleFinderRing:
+		return "FinderRing"
+	case RoleFinderCenter:
+		return "FinderCenter"
+	case RoleSeparator:
+		return "Separator"
+	case RoleAlignment:
+		return "Alignment"
+	case RoleTiming:
+		return "Timing"
+	case RoleFormatInfo:
+		return "FormatInfo"
+	case RoleVersionInfo:
+		return "VersionInfo"
+	}
+	return "ModuleRole(?)"
+}
+
+// ClassifyModules returns the role of every module of a symbol version,
+// indexed [row][col] like QRCode.Matrix
+func ClassifyModules(version int) ([][]ModuleRole, error) {
+	if version < 1 || version > 40 {
+		return nil, fmt.Errorf("invalid version %d", version)
+	}
+	size := getVersionInfo(version).Size
+
+	roles := make([][]ModuleRole, size)
+	for i := range roles {
+		roles[i] = make([]ModuleRole, size)
+	}
+	set := func(x, y int, role ModuleRole) {
+		if x >= 0 && x < size && y >= 0 && y < size {
+			roles[y][x] = role
+		}
+	}
+
+	// Later patterns take precedence where they overlap, following the
+	// order newSymbolMatrix places them in
+	for i := 8; i < size-8; i++ {
+		set(i, 6, RoleTiming)
+		set(6, i, RoleTiming)
+	}
+
+	for _, center := range alignmentPatternCenters(version) {
+		for dy := -2; dy <= 2; dy++ {
+			for dx := -2; dx <= 2; dx++ {
+				set(center[0]+dx, center[1]+dy, RoleAlignment)
+			}
+		}
+	}
+
+	for i := 0; i < 9; i++ {
+		if i == 6 {
+			continue // Timing pattern crossing
+		}
+		set(8, i, RoleFormatInfo)
+		set(i, 8, RoleFormatInfo)
+	}
+	for i := 0; i < 8; i++ {
+		set(size-1-i, 8, RoleFormatInfo)
+		set(8, size-1-i, RoleFormatInfo)
+	}
+
+	if version >= 7 {
+		for i := 0; i < 18; i++ {
+			a := size - 11 + i%3
+			b := i / 3
+			set(b, a, RoleVersionInfo)
+			set(a, b, RoleVersionInfo)
+		}
+	}
+
+	for _, origin := range [][2]int{{0, 0}, {size - 7, 0}, {0, size - 7}} {
+		for dy := -1; dy <= 7; dy++ {
+			for dx := -1; dx <= 7; dx++ {
+				role := RoleFinderRing
+				switch {
+				case dx == -1 || dx == 7 || dy == -1 || dy == 7:
+					role = RoleSeparator
+				case dx >= 2 && dx <= 4 && dy >= 2 && dy <= 4:
+					role = RoleFinderCenter
+				}
+				set(origin[0]+dx, origin[1]+dy, role)
+			}
+		}
+	}
+
+	return roles, nil
+}
+
+// ModuleRoles returns the role of every module of the encoded symbol, or
+// nil before Encode has chosen a version
+func (qr *QRCode) ModuleRoles() [][]ModuleRole {
+	roles, err := ClassifyModules(qr.Version)
+	if err != nil {
+		return nil
+	}
+	return roles
+}
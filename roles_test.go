@@ -0,0 +1,176 @@
+package myqrcode
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestClassifyModulesMatchesLayout(t *testing.T) {
+	// Every function role must be exactly the reserved area of the encoder layout
+	for version := 1; version <= 40; version++ {
+		roles, err := ClassifyModules(version)
+		if err != nil {
+			t.Fatalf("Version %d: %v", version, err)
+		}
+		layout := newSymbolMatrix(version)
+
+		for y := 0; y < layout.Size; y++ {
+			for x := 0; x < layout.Size; x++ {
+				isData := roles[y][x] == RoleData
+				if isData == layout.IsReserved(x, y) {
+					t.Fatalf("Version %d module (%d, %d): role %s, reserved %v",
+						version, x, y, roles[y][x], layout.IsReserved(x, y))
+				}
+			}
+		}
+	}
+}
+
+func TestClassifyModulesCounts(t *testing.T) {
+	count := func(roles [][]ModuleRole) map[ModuleRole]int {
+		counts := make(map[ModuleRole]int)
+		for _, row := range roles {
+			for _, role := range row {
+				counts[role]++
+			}
+		}
+		return counts
+	}
+
+	classify := func(version int) [][]ModuleRole {
+		roles, err := ClassifyModules(version)
+		if err != nil {
+			t.Fatalf("Version %d: %v", version, err)
+		}
+		return roles
+	}
+
+	v1 := count(classify(1))
+	if v1[RoleFinderCenter] != 27 || v1[RoleFinderRing] != 120 {
+		t.Errorf("Version 1 finder modules: %d center, %d ring", v1[RoleFinderCenter], v1[RoleFinderRing])
+	}
+	if v1[RoleAlignment] != 0 || v1[RoleVersionInfo] != 0 {
+		t.Error("Version 1 should have no alignment patterns or version information")
+	}
+	if v1[RoleFormatInfo] != 31 {
+		t.Errorf("Version 1 format modules: %d, want 31", v1[RoleFormatInfo])
+	}
+
+	v7 := count(classify(7))
+	if v7[RoleAlignment] != 6*25 {
+		t.Errorf("Version 7 alignment modules: %d, want %d", v7[RoleAlignment], 6*25)
+	}
+	if v7[RoleVersionInfo] != 36 {
+		t.Errorf("Version 7 version modules: %d, want 36", v7[RoleVersionInfo])
+	}
+}
+
+func TestClassifyModulesInvalidVersion(t *testing.T) {
+	for _, version := range []int{0, -1, 41} {
+		if _, err := ClassifyModules(version); err == nil {
+			t.Errorf("Expected an error for version %d", version)
+		}
+	}
+}
+
+func TestRoleColorsNormalized(t *testing.T) {
+	qr, err := New("https://meet.google.com/abc-defg-hij", Medium)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	// The same color in another type is not a separate color
+	config := DefaultStyleConfig()
+	config.ForegroundColor = color.RGBA{20, 40, 120, 255}
+	config.RoleColors = map[ModuleRole]color.Color{RoleFinderRing: color.NRGBA{20, 40, 120, 255}}
+	if n := len(config.foregroundColors()); n != 1 {
+		t.Errorf("Expected one foreground color, got %d", n)
+	}
+
+	img, err := qr.ToImage(config)
+	if err != nil {
+		t.Fatalf("Failed to generate image: %v", err)
+	}
+	// Top-left finder ring, drawn with the ForegroundColor pass
+	x, y := config.QuietZone+config.ModuleSize/2, config.QuietZone+config.ModuleSize/2
+	if !sameColor(img.At(x, y), config.ForegroundColor) {
+		t.Errorf("Expected the finder ring in the foreground color, got %v", img.At(x, y))
+	}
+}
+
+func TestRoleColors(t *testing.T) {
+	qr, err := New("https://meet.google.com/abc-defg-hij", Medium)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	ring := color.RGBA{200, 30, 30, 255}
+	center := color.RGBA{20, 40, 200, 255}
+	alignment := color.RGBA{20, 140, 40, 255}
+
+	drawers := map[string]ModuleDrawer{
+		"square":  NewSquareModuleDrawer(),
+		"circle":  NewCircleModuleDrawer(),
+		"rounded": NewRoundedModuleDrawer(1.0),
+		"gapped":  NewGappedCircleModuleDrawer(0.9),
+	}
+
+	for name, drawer := range drawers {
+		for _, withFinderDrawer := range []bool{false, true} {
+			config := DefaultStyleConfig()
+			config.ModuleSize = 10
+			config.QuietZone = 40
+			config.ModuleDrawer = drawer
+			WithRoleColor(RoleFinderRing, ring)(&config)
+			WithRoleColor(RoleFinderCenter, center)(&config)
+			WithRoleColor(RoleAlignment, alignment)(&config)
+			if withFinderDrawer {
+				config.FinderDrawer = NewFinderDrawer(EyeRounded, EyeCircle)
+			}
+
+			img, err := qr.ToImage(config)
+			if err != nil {
+				t.Fatalf("Failed to generate image: %v", err)
+			}
+
+			at := func(mx, my int) color.RGBA {
+				return color.RGBAModel.Convert(img.At(40+mx*10+5, 40+my*10+5)).(color.RGBA)
+			}
+
+			if got := at(3, 0); got != ring {
+				t.Errorf("%s: finder ring color %v, want %v", name, got, ring)
+			}
+			if got := at(3, 3); got != center {
+				t.Errorf("%s: finder center color %v, want %v", name, got, center)
+			}
+
+			// Version 3 has one alignment pattern centered at (22, 22)
+			if got := at(20, 22); got != alignment {
+				t.Errorf("%s: alignment color %v, want %v", name, got, alignment)
+			}
+
+			// Dark data modules keep the default foreground
+			roles := qr.ModuleRoles()
+			for y := 0; y < qr.Size; y++ {
+				for x := 0; x < qr.Size; x++ {
+					if roles[y][x] == RoleData && qr.Matrix[y][x] {
+						if got := at(x, y); got != (color.RGBA{0, 0, 0, 255}) {
+							t.Fatalf("%s: data module (%d, %d) color %v, want black", name, x, y, got)
+						}
+						x, y = qr.Size, qr.Size
+					}
+				}
+			}
+
+			if name == "circle" && withFinderDrawer {
+				saveTestImage(t, img, "test_role_colors.png")
+			}
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package myqrcode
+
+import (
+	"image"
+	"math"
+)
+
+// BarModuleDrawer joins runs of modules into pill-shaped bars, horizontally
+// or vertically. Ratio is the bar thickness as a share of the module size.
+type BarModuleDrawer struct {
+	BaseModuleDrawer
+	Vertical bool
+	Ratio    float64
+
+	masks map[ActiveWithNeighbors]*image.Alpha // By the neighbors along the bar
+}
+
+func NewHorizontalBarsModuleDrawer(ratio float64) *BarModuleDrawer {
+	return newBarModuleDrawer(false, ratio)
+}
+
+func NewVerticalBarsModuleDrawer(ratio float64) *BarModuleDrawer {
+	return newBarModuleDrawer(true, ratio)
+}
+
+func newBarModuleDrawer(vertical bool, ratio float64) *BarModuleDrawer {
+	if ratio <= 0 || ratio > 1 {
+		ratio = 0.8
+	}
+	return &BarModuleDrawer{Vertical: vertical, Ratio: ratio}
+}
+
+func (b *BarModuleDrawer) NeedsNeighbors() bool {
+	return true
+}
+
+func (b *BarModuleDrawer) Initialize(img *image.RGBA, config StyleConfig) {
+	b.BaseModuleDrawer.Initialize(img, config)
+	b.masks = make(map[ActiveWithNeighbors]*image.Alpha)
+}
+
+func (b *BarModuleDrawer) DrawModule(box [4]int, isActive bool, neighbors *ActiveWithNeighbors) {
+	if !isActive || neighbors == nil {
+		return
+	}
+
+	key := ActiveWithNeighbors{W: neighbors.W, E: neighbors.E}
+	if b.Vertical {
+		key = ActiveWithNeighbors{N: neighbors.N, S: neighbors.S}
+	}
+	width := box[2] - box[0]
+	mask, ok := b.masks[key]
+	if !ok {
+		mask = contourMask(width, width, b.config.Quality, []*contour{b.shape(float32(width), key)})
+		b.masks[key] = mask
+	}
+
+	fillMask(b.img, image.Rect(box[0], box[1], box[2], box[3]), b.config.ForegroundColor, mask)
+}
+
+// shape builds the bar piece of an m pixel module
+func (b *BarModuleDrawer) shape(m float32, neighbors ActiveWithNeighbors) *contour {
+	thickness := float32(b.Ratio) * m
+	inset := (m - thickness) / 2
+	r := thickness / 2
+
+	// Round the ends of the bar; sides facing a neighbor in the run stay square
+	if b.Vertical {
+		top, bottom := r, r
+		if neighbors.N {
+			top = 0
+		}
+		if neighbors.S {
+			bottom = 0
+		}
+		return roundedRect(inset, 0, thickness, m, [4]float32{top, top, bottom, bottom})
+	}
+	left, right := r, r
+	if neighbors.W {
+		left = 0
+	}
+	if neighbors.E {
+		right = 0
+	}
+	return roundedRect(0, inset, m, thickness, [4]float32{left, right, right, left})
+}
+
+// LiquidModuleDrawer merges neighboring modules into smooth blobs: outer
+// corners are rounded and inner corners get concave fillets. Ratio is the
+// corner radius as a share of half a module.
+type LiquidModuleDrawer struct {
+	BaseModuleDrawer
+	Ratio float64
+
+	masks map[ActiveWithNeighbors]*image.Alpha
+}
+
+func NewLiquidModuleDrawer(ratio float64) *LiquidModuleDrawer {
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1.0
+	}
+	return &LiquidModuleDrawer{Ratio: ratio}
+}
+
+func (l *LiquidModuleDrawer) NeedsNeighbors() bool {
+	return true
+}
+
+func (l *LiquidModuleDrawer) Initialize(img *image.RGBA, config StyleConfig) {
+	l.BaseModuleDrawer.Initialize(img, config)
+	l.masks = make(map[ActiveWithNeighbors]*image.Alpha)
+}
+
+func (l *LiquidModuleDrawer) DrawModule(box [4]int, isActive bool, neighbors *ActiveWithNeighbors) {
+	if !isActive || neighbors == nil {
+		return
+	}
+
+	width := box[2] - box[0]
+	mask, ok := l.masks[*neighbors]
+	if !ok {
+		mask = contourMask(3*width, 3*width, l.config.Quality, l.shapes(float32(width), neighbors))
+		l.masks[*neighbors] = mask
+	}
+
+	bounds := image.Rect(box[0]-width, box[1]-width, box[2]+width, box[3]+width)
+	fillMask(l.img, bounds, l.config.ForegroundColor, mask)
+}
+
+// shapes builds the blob piece of an m pixel module, in a 3x3 module area
+// with the module itself in the middle
+func (l *LiquidModuleDrawer) shapes(m float32, n *ActiveWithNeighbors) []*contour {
+	r := float32(l.Ratio) * m / 2
+
+	// Outer corners: round where both adjacent sides are open
+	radius := func(a, b bool) float32 {
+		if a || b {
+			return 0
+		}
+		return r
+	}
+
+	// The area covers the neighboring modules too, so fillets can reach
+	// into them. The module itself sits at (m, m).
+	shapes := []*contour{roundedRect(m, m, m, m, [4]float32{
+		radius(n.N, n.W), radius(n.N, n.E), radius(n.S, n.E), radius(n.S, n.W),
+	})}
+
+	// Inner corners: two orthogonal neighbors are set but the diagonal one
+	// between them is not. The fillet fills the corner of that diagonal
+	// module, so only the module opposite it draws it.
+	if n.N && n.E && !n.NE {
+		shapes = append(shapes, fillet(2*m, m, 1, -1, r))
+	}
+	if n.S && n.E && !n.SE {
+		shapes = append(shapes, fillet(2*m, 2*m, 1, 1, r))
+	}
+	if n.S && n.W && !n.SW {
+		shapes = append(shapes, fillet(m, 2*m, -1, 1, r))
+	}
+	if n.N && n.W && !n.NW {
+		shapes = append(shapes, fillet(m, m, -1, -1, r))
+	}
+	return shapes
+}
+
+// fillet builds the concave corner piece at corner point (cx, cy): the r x r
+// square reaching in direction (sx, sy), minus a quarter circle of radius r
+func fillet(cx, cy, sx, sy, r float32) *contour {
+	k := float32(kappa)
+	c := &contour{start: point{cx, cy}}
+	c.lineTo(cx, cy+sy*r)
+	c.cubeTo(cx, cy+sy*(r-r*k), cx+sx*(r-r*k), cy, cx+sx*r, cy)
+	return c
+}
+
+// DiamondModuleDrawer draws each module as a square rotated 45 degrees.
+// Ratio is the diagonal as a share of the module size.
+type DiamondModuleDrawer struct {
+	BaseModuleDrawer
+	Ratio float64
+
+	mask *image.Alpha
+}
+
+func NewDiamondModuleDrawer(ratio float64) *DiamondModuleDrawer {
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1.0
+	}
+	return &DiamondModuleDrawer{Ratio: ratio}
+}
+
+func (d *DiamondModuleDrawer) Initialize(img *image.RGBA, config StyleConfig) {
+	d.BaseModuleDrawer.Initialize(img, config)
+	d.mask = nil
+}
+
+func (d *DiamondModuleDrawer) DrawModule(box [4]int, isActive bool, neighbors *ActiveWithNeighbors) {
+	if !isActive {
+		return
+	}
+
+	if d.mask == nil {
+		width := box[2] - box[0]
+		c := float32(width) / 2
+		r := float32(d.Ratio) * c
+		shape := polygon(point{c, c - r}, point{c + r, c}, point{c, c + r}, point{c - r, c})
+		d.mask = contourMask(width, width, d.config.Quality, []*contour{shape})
+	}
+
+	fillMask(d.img, image.Rect(box[0], box[1], box[2], box[3]), d.config.ForegroundColor, d.mask)
+}
+
+// StarModuleDrawer draws each module as a five-pointed star.
+// Ratio is the outer diameter as a share of the module size.
+type StarModuleDrawer struct {
+	BaseModuleDrawer
+	Ratio float64
+
+	mask *image.Alpha
+}
+
+func NewStarModuleDrawer(ratio float64) *StarModuleDrawer {
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1.0
+	}
+	return &StarModuleDrawer{Ratio: ratio}
+}
+
+func (s *StarModuleDrawer) Initialize(img *image.RGBA, config StyleConfig) {
+	s.BaseModuleDrawer.Initialize(img, config)
+	s.mask = nil
+}
+
+func (s *StarModuleDrawer) DrawModule(box [4]int, isActive bool, neighbors *ActiveWithNeighbors) {
+	if !isActive {
+		return
+	}
+
+	if s.mask == nil {
+		width := box[2] - box[0]
+		c := float32(width) / 2
+		outer := float64(s.Ratio) * float64(c)
+		inner := outer * 0.5
+
+		// Alternate outer and inner points, starting straight up
+		points := make([]point, 10)
+		for i := range points {
+			radius := outer
+			if i%2 == 1 {
+				radius = inner
+			}
+			sin, cos := math.Sincos(-math.Pi/2 + float64(i)*math.Pi/5)
+			points[i] = point{c + float32(radius*cos), c + float32(radius*sin)}
+		}
+		s.mask = contourMask(width, width, s.config.Quality, []*contour{polygon(points...)})
+	}
+
+	fillMask(s.img, image.Rect(box[0], box[1], box[2], box[3]), s.config.ForegroundColor, s.mask)
+}
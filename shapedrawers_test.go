@@ -0,0 +1,160 @@
+package myqrcode
+
+import (
+	"image"
+	"image/draw"
+	"testing"
+)
+
+func TestShapeModuleDrawers(t *testing.T) {
+	qr, err := New("https://meet.google.com/abc-defg-hij", High)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	drawers := map[string]ModuleDrawer{
+		"hbars":   NewHorizontalBarsModuleDrawer(0.8),
+		"vbars":   NewVerticalBarsModuleDrawer(0.8),
+		"liquid":  NewLiquidModuleDrawer(1.0),
+		"diamond": NewDiamondModuleDrawer(1.0),
+		"star":    NewStarModuleDrawer(1.0),
+		"rounded": NewRoundedModuleDrawer(1.0),
+	}
+
+	const ms, qz = 10, 40
+	for name, drawer := range drawers {
+		t.Run(name, func(t *testing.T) {
+			config := DefaultStyleConfig()
+			config.ModuleSize = ms
+			config.QuietZone = qz
+			config.ModuleDrawer = drawer
+
+			img, err := qr.ToImage(config)
+			if err != nil {
+				t.Fatalf("Failed to generate image: %v", err)
+			}
+
+			for y := 0; y < qr.Size; y++ {
+				for x := 0; x < qr.Size; x++ {
+					if isDark(img, qz+x*ms+ms/2, qz+y*ms+ms/2) != qr.Matrix[y][x] {
+						t.Fatalf("Module (%d, %d) center does not match its bit", x, y)
+					}
+				}
+			}
+
+			saveTestImage(t, img, "test_drawer_"+name+".png")
+		})
+	}
+}
+
+func TestConnectedDrawerShapes(t *testing.T) {
+	// A 2x2 L shape: (0,0), (0,1) and (1,1) set, (1,0) clear
+	matrix := [][]bool{
+		{true, false, false},
+		{true, true, false},
+		{false, false, false},
+	}
+	const ms = 20
+
+	render := func(drawer ModuleDrawer) func(x, y int) bool {
+		config := DefaultStyleConfig()
+		config.ModuleSize = ms
+
+		img := image.NewRGBA(image.Rect(0, 0, 5*ms, 5*ms))
+		draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+		drawer.Initialize(img, config)
+
+		// Module (0, 0) is drawn at (ms, ms)
+		for y := range matrix {
+			for x := range matrix[y] {
+				box := [4]int{ms + x*ms, ms + y*ms, 2*ms + x*ms, 2*ms + y*ms}
+				drawer.DrawModule(box, matrix[y][x], GetModuleNeighbors(matrix, y, x))
+			}
+		}
+		return func(x, y int) bool { return isDark(img, ms+x, ms+y) }
+	}
+
+	// Horizontal bars: (0,1)-(1,1) joined, (0,0)-(0,1) separated
+	at := render(NewHorizontalBarsModuleDrawer(0.6))
+	if !at(ms, ms+ms/2) {
+		t.Error("Horizontal bars: expected joined run")
+	}
+	if at(ms/2, ms) {
+		t.Error("Horizontal bars: expected gap between rows")
+	}
+
+	// Vertical bars: the opposite
+	at = render(NewVerticalBarsModuleDrawer(0.6))
+	if !at(ms/2, ms) {
+		t.Error("Vertical bars: expected joined run")
+	}
+	if at(ms, ms+ms/2) {
+		t.Error("Vertical bars: expected gap between columns")
+	}
+
+	// Liquid: the inner corner at (ms, ms) is filleted into module (1,0),
+	// the outer top-left corner is rounded away
+	at = render(NewLiquidModuleDrawer(1.0))
+	if !at(ms+1, ms-2) {
+		t.Error("Liquid: expected concave fillet at the inner corner")
+	}
+	if at(ms+ms/2, ms/2) {
+		t.Error("Liquid: fillet must not reach the clear module's center")
+	}
+	if at(1, 1) {
+		t.Error("Liquid: expected rounded outer corner")
+	}
+	if !at(1, ms) {
+		t.Error("Liquid: expected straight edge between joined modules")
+	}
+}
+
+func TestShapeDrawerMaskCache(t *testing.T) {
+	qr, err := New("https://meet.google.com/abc-defg-hij", Medium)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	bars := NewHorizontalBarsModuleDrawer(0.7)
+	liquid := NewLiquidModuleDrawer(1)
+	star := NewStarModuleDrawer(1)
+	for _, drawer := range []ModuleDrawer{bars, liquid, star} {
+		config := DefaultStyleConfig()
+		config.ModuleSize = 9
+		config.ModuleDrawer = drawer
+		if _, err := qr.ToImage(config); err != nil {
+			t.Fatalf("Failed to generate image: %v", err)
+		}
+
+		// The same drawer is rendered again at another quality: its masks
+		// must be rebuilt, not reused
+		config.Quality = QualityAliased
+		img, err := qr.ToImage(config)
+		if err != nil {
+			t.Fatalf("Failed to generate image: %v", err)
+		}
+		rgba := img.(*image.RGBA)
+		for i := 0; i < len(rgba.Pix); i += 4 {
+			if v := rgba.Pix[i]; v != 0 && v != 255 {
+				t.Fatalf("%T: expected aliased output after a quality change, got %d", drawer, v)
+			}
+		}
+	}
+
+	// One mask per distinct neighbor configuration
+	if len(bars.masks) > 4 {
+		t.Errorf("Expected at most 4 bar masks, got %d", len(bars.masks))
+	}
+	if len(liquid.masks) == 0 || len(liquid.masks) > 256 {
+		t.Errorf("Expected between 1 and 256 liquid masks, got %d", len(liquid.masks))
+	}
+	if star.mask == nil {
+		t.Error("Expected the star mask to be cached")
+	}
+}
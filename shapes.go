@@ -0,0 +1,190 @@
+package myqrcode
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"golang.org/x/image/vector"
+)
+
+// kappa is the control point distance for approximating a quarter circle with a cubic Bézier
+const kappa = 0.5522847498
+
+// point is a 2D coordinate in rasterizer space
+type point struct {
+	X, Y float32
+}
+
+// pathSegment is a line (when curve is false) or a cubic Bézier ending at p
+type pathSegment struct {
+	c1, c2 point
+	p      point
+	curve  bool
+}
+
+// contour is a closed outline that can be added to a rasterizer in either direction.
+// The rasterizer accumulates signed coverage, so a contour added in reverse
+// inside another one cuts a hole in it.
+type contour struct {
+	start    point
+	segments []pathSegment
+}
+
+func (c *contour) lineTo(x, y float32) {
+	c.segments = append(c.segments, pathSegment{p: point{x, y}})
+}
+
+func (c *contour) cubeTo(c1x, c1y, c2x, c2y, x, y float32) {
+	c.segments = append(c.segments, pathSegment{c1: point{c1x, c1y}, c2: point{c2x, c2y}, p: point{x, y}, curve: true})
+}
+
+// mapped returns a copy of the contour with every point passed through f
+func (c *contour) mapped(f func(point) point) *contour {
+	out := &contour{start: f(c.start), segments: make([]pathSegment, len(c.segments))}
+	for i, s := range c.segments {
+		out.segments[i] = pathSegment{c1: f(s.c1), c2: f(s.c2), p: f(s.p), curve: s.curve}
+	}
+	return out
+}
+
+// addTo draws the contour into z, clockwise as built or counter-clockwise when reverse is set
+func (c *contour) addTo(z *vector.Rasterizer, reverse bool) {
+	if !reverse {
+		z.MoveTo(c.start.X, c.start.Y)
+		from := c.start
+		for _, s := range c.segments {
+			if s.curve {
+				cubeTo(z, from, s.c1, s.c2, s.p)
+			} else {
+				z.LineTo(s.p.X, s.p.Y)
+			}
+			from = s.p
+		}
+		z.ClosePath()
+		return
+	}
+
+	// Walk the segments backwards; each segment ends where the previous one started
+	end := c.start
+	if len(c.segments) > 0 {
+		end = c.segments[len(c.segments)-1].p
+	}
+	z.MoveTo(end.X, end.Y)
+	for i := len(c.segments) - 1; i >= 0; i-- {
+		s := c.segments[i]
+		from := c.start
+		if i > 0 {
+			from = c.segments[i-1].p
+		}
+		if s.curve {
+			cubeTo(z, s.p, s.c2, s.c1, from)
+		} else {
+			z.LineTo(from.X, from.Y)
+		}
+	}
+	z.ClosePath()
+}
+
+// flatTolerance is the largest distance, in pixels, between a curve and the
+// lines that stand in for it. The rasterizer's own flattening is coarse
+// enough to visibly shrink module-sized circles.
+const flatTolerance = 0.02
+
+// cubeTo adds the cubic Bézier from a to d as line segments, enough of them
+// to stay within flatTolerance of the curve
+func cubeTo(z *vector.Rasterizer, a, b, c, d point) {
+	dev := func(p, q, r point) float64 {
+		return math.Hypot(float64(p.X-2*q.X+r.X), float64(p.Y-2*q.Y+r.Y))
+	}
+	// Chord error is at most 6·dev/(8n²) for n even steps
+	n := int(math.Ceil(math.Sqrt(0.75 * max(dev(a, b, c), dev(b, c, d)) / flatTolerance)))
+	for i := 1; i < n; i++ {
+		t := float32(i) / float32(n)
+		u := 1 - t
+		w0, w1, w2, w3 := u*u*u, 3*u*u*t, 3*u*t*t, t*t*t
+		z.LineTo(w0*a.X+w1*b.X+w2*c.X+w3*d.X, w0*a.Y+w1*b.Y+w2*c.Y+w3*d.Y)
+	}
+	z.LineTo(d.X, d.Y)
+}
+
+// roundedRect builds a clockwise rectangle outline with per-corner radii
+// in the order top-left, top-right, bottom-right, bottom-left
+func roundedRect(x, y, w, h float32, radii [4]float32) *contour {
+	// Clamp radii so opposite corners never overlap
+	limit := min(w, h) / 2
+	for i := range radii {
+		radii[i] = max(0, min(radii[i], limit))
+	}
+	tl, tr, br, bl := radii[0], radii[1], radii[2], radii[3]
+	k := float32(kappa)
+
+	c := &contour{start: point{x + tl, y}}
+
+	c.lineTo(x+w-tr, y)
+	if tr > 0 {
+		c.cubeTo(x+w-tr+tr*k, y, x+w, y+tr-tr*k, x+w, y+tr)
+	}
+
+	c.lineTo(x+w, y+h-br)
+	if br > 0 {
+		c.cubeTo(x+w, y+h-br+br*k, x+w-br+br*k, y+h, x+w-br, y+h)
+	}
+
+	c.lineTo(x+bl, y+h)
+	if bl > 0 {
+		c.cubeTo(x+bl-bl*k, y+h, x, y+h-bl+bl*k, x, y+h-bl)
+	}
+
+	c.lineTo(x, y+tl)
+	if tl > 0 {
+		c.cubeTo(x, y+tl-tl*k, x+tl-tl*k, y, x+tl, y)
+	}
+
+	return c
+}
+
+// circle builds a clockwise circle outline
+func circle(cx, cy, r float32) *contour {
+	return roundedRect(cx-r, cy-r, 2*r, 2*r, [4]float32{r, r, r, r})
+}
+
+// drawContours fills contours given relative to bounds.Min onto img at the
+// quality, compositing with draw.Over
+func drawContours(img *image.RGBA, bounds image.Rectangle, c color.Color, quality RenderQuality, contours ...*contour) {
+	fillMask(img, bounds, c, contourMask(bounds.Dx(), bounds.Dy(), quality, contours))
+}
+
+// fillMask composites c through mask onto img at bounds
+func fillMask(img *image.RGBA, bounds image.Rectangle, c color.Color, mask *image.Alpha) {
+	draw.DrawMask(img, bounds, &image.Uniform{c}, image.Point{}, mask, image.Point{}, draw.Over)
+}
+
+// contourMask renders the coverage of contours in a w×h area at the
+// quality. Holes are traced in reverse, cutting into the contours.
+func contourMask(w, h int, quality RenderQuality, contours []*contour, holes ...*contour) *image.Alpha {
+	return quality.coverage(func(scale int) *image.Alpha {
+		s := float32(scale)
+		grow := func(p point) point { return point{p.X * s, p.Y * s} }
+		z := vector.NewRasterizer(w*scale, h*scale)
+		for _, c := range contours {
+			c.mapped(grow).addTo(z, false)
+		}
+		for _, c := range holes {
+			c.mapped(grow).addTo(z, true)
+		}
+		m := image.NewAlpha(image.Rect(0, 0, w*scale, h*scale))
+		z.Draw(m, m.Bounds(), image.Opaque, image.Point{})
+		return m
+	})
+}
+
+// polygon builds a closed outline through the given points
+func polygon(points ...point) *contour {
+	c := &contour{start: points[0]}
+	for _, p := range points[1:] {
+		c.lineTo(p.X, p.Y)
+	}
+	return c
+}
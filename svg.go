@@ -0,0 +1,222 @@
+package myqrcode
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ToSVG renders the code as an SVG document. Modules are drawn as squares in
+// their role colors, one path per color. The logo and overlays are embedded
+// as images with their styles; an SVGLogo keeps its original document, so
+// the logo stays vector. Module drawers, fills, background images and
+// frames only apply to ToImage.
+func (qr *QRCode) ToSVG(config StyleConfig) ([]byte, error) {
+	if qr.Matrix == nil {
+		return nil, errors.New("QR code not encoded")
+	}
+
+	moduleSize := config.ModuleSize
+	if moduleSize <= 0 {
+		moduleSize = 8
+	}
+	quietZone := config.QuietZone
+	if quietZone <= 0 {
+		quietZone = 4 * moduleSize
+	}
+	if config.BackgroundColor == nil {
+		config.BackgroundColor = color.RGBA{255, 255, 255, 255}
+	}
+	if config.ForegroundColor == nil {
+		config.ForegroundColor = color.RGBA{0, 0, 0, 255}
+	}
+	if config.Inverted {
+		config.ForegroundColor, config.BackgroundColor = config.BackgroundColor, config.ForegroundColor
+	}
+
+	// Blended overlays leave their modules in place; check how they read on
+	// a raster of the same square modules
+	for _, o := range qr.overlays() {
+		if o.Mode == LogoBlend {
+			raster := StyleConfig{
+				ModuleSize:      moduleSize,
+				QuietZone:       quietZone,
+				ForegroundColor: config.ForegroundColor,
+				BackgroundColor: config.BackgroundColor,
+				RoleColors:      config.RoleColors,
+			}
+			if _, err := qr.ToImage(raster); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	imgSize := qr.Size*moduleSize + 2*quietZone
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", imgSize, imgSize, imgSize, imgSize)
+
+	if _, _, _, a := config.BackgroundColor.RGBA(); a > 0 {
+		fmt.Fprintf(&buf, `<rect width="%d" height="%d"%s/>`+"\n", imgSize, imgSize, svgFill(config.BackgroundColor))
+	}
+
+	// One path per color, with runs of dark modules merged along each row
+	roles := qr.ModuleRoles()
+	for _, fg := range config.foregroundColors() {
+		var d strings.Builder
+		for y := 0; y < qr.Size; y++ {
+			for x := 0; x < qr.Size; {
+				if !qr.Matrix[y][x] || !sameRGBA(config.colorFor(roles[y][x]), fg) {
+					x++
+					continue
+				}
+				run := x
+				for run < qr.Size && qr.Matrix[y][run] && sameRGBA(config.colorFor(roles[y][run]), fg) {
+					run++
+				}
+				fmt.Fprintf(&d, "M%d %dh%dv%dh-%dz", quietZone+x*moduleSize, quietZone+y*moduleSize, (run-x)*moduleSize, moduleSize, (run-x)*moduleSize)
+				x = run
+			}
+		}
+		if d.Len() > 0 {
+			fmt.Fprintf(&buf, `<path%s shape-rendering="crispEdges" d="%s"/>`+"\n", svgFill(fg), d.String())
+		}
+	}
+
+	var placements []LogoPlacement
+	for i, o := range qr.overlays() {
+		placement := o.placement(qr.Version)
+		area := image.Rect(0, 0, placement.Width*moduleSize, placement.Height*moduleSize).
+			Add(image.Pt(quietZone+placement.X*moduleSize, quietZone+placement.Y*moduleSize))
+		if err := writeSVGOverlay(&buf, o, area, i, config); err != nil {
+			return nil, err
+		}
+		placements = append(placements, placement)
+	}
+
+	// Dark alignment modules stay visible on top of the overlays, which show
+	// between them
+	var d strings.Builder
+	for _, m := range qr.alignmentUnder(placements) {
+		if qr.Matrix[m.Y][m.X] {
+			fmt.Fprintf(&d, "M%d %dh%dv%dh-%dz", quietZone+m.X*moduleSize, quietZone+m.Y*moduleSize, moduleSize, moduleSize, moduleSize)
+		}
+	}
+	if d.Len() > 0 {
+		fmt.Fprintf(&buf, `<path%s shape-rendering="crispEdges" d="%s"/>`+"\n", svgFill(config.colorFor(RoleAlignment)), d.String())
+	}
+
+	buf.WriteString("</svg>\n")
+	return buf.Bytes(), nil
+}
+
+// writeSVGOverlay writes an overlay the way paint draws it: stretched over
+// its area without a style, or fitted inside the plate, border and padding
+// and clipped to the style's shape
+func writeSVGOverlay(buf *bytes.Buffer, o Overlay, area image.Rectangle, index int, config StyleConfig) error {
+	href, err := svgImageHref(o.Image)
+	if err != nil {
+		return err
+	}
+
+	if o.Opacity > 0 && o.Opacity < 1 {
+		fmt.Fprintf(buf, `<g opacity="%s">`+"\n", svgNumber(o.Opacity))
+	}
+
+	style := o.Style
+	if style == nil {
+		fmt.Fprintf(buf, `<image x="%d" y="%d" width="%d" height="%d" preserveAspectRatio="none" href="%s"/>`+"\n",
+			area.Min.X, area.Min.Y, area.Dx(), area.Dy(), href)
+	} else {
+		w, h := float32(area.Dx()), float32(area.Dy())
+		offset := point{float32(area.Min.X), float32(area.Min.Y)}
+
+		plateColor := style.PlateColor
+		if plateColor == nil {
+			plateColor = config.BackgroundColor
+		}
+		if plate := style.logoContour(w, h, 0); plate != nil && o.Mode != LogoBlend {
+			fmt.Fprintf(buf, `<path%s d="%s"/>`+"\n", svgFill(plateColor), plate.pathData(offset))
+		}
+
+		border := float32(max(0, style.BorderWidth))
+		if outer := style.logoContour(w, h, 0); outer != nil && border > 0 {
+			borderColor := style.BorderColor
+			if borderColor == nil {
+				borderColor = config.ForegroundColor
+			}
+			d := outer.pathData(offset)
+			if inner := style.logoContour(w, h, border); inner != nil {
+				d += inner.pathData(offset)
+			}
+			fmt.Fprintf(buf, `<path%s fill-rule="evenodd" d="%s"/>`+"\n", svgFill(borderColor), d)
+		}
+
+		inset := border + float32(max(0, style.Padding))
+		if clip := style.logoContour(w, h, inset); clip != nil {
+			box := area.Inset(int(inset))
+			id := "logo-clip-" + strconv.Itoa(index)
+			fmt.Fprintf(buf, `<clipPath id="%s"><path d="%s"/></clipPath>`+"\n", id, clip.pathData(offset))
+			fmt.Fprintf(buf, `<image x="%d" y="%d" width="%d" height="%d" preserveAspectRatio="xMidYMid meet" clip-path="url(#%s)" href="%s"/>`+"\n",
+				box.Min.X, box.Min.Y, box.Dx(), box.Dy(), id, href)
+		}
+	}
+
+	if o.Opacity > 0 && o.Opacity < 1 {
+		buf.WriteString("</g>\n")
+	}
+	return nil
+}
+
+// svgImageHref returns a data URI for the image: the original document for
+// an SVGLogo, a PNG for anything else
+func svgImageHref(img image.Image) (string, error) {
+	if logo, ok := img.(*SVGLogo); ok {
+		return "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString(logo.Document()), nil
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", fmt.Errorf("failed to encode overlay: %w", err)
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// svgFill returns the fill attribute, and fill-opacity when translucent
+func svgFill(c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	attr := fmt.Sprintf(` fill="#%02x%02x%02x"`, n.R, n.G, n.B)
+	if n.A < 255 {
+		attr += fmt.Sprintf(` fill-opacity="%s"`, svgNumber(float64(n.A)/255))
+	}
+	return attr
+}
+
+// svgNumber formats a coordinate with at most two decimals
+func svgNumber(f float64) string {
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
+
+// pathData returns the contour as SVG path data, moved by offset
+func (c *contour) pathData(offset point) string {
+	var d strings.Builder
+	p := func(q point) string {
+		return svgNumber(float64(q.X+offset.X)) + " " + svgNumber(float64(q.Y+offset.Y))
+	}
+	d.WriteString("M" + p(c.start))
+	for _, s := range c.segments {
+		if s.curve {
+			d.WriteString("C" + p(s.c1) + " " + p(s.c2) + " " + p(s.p))
+		} else {
+			d.WriteString("L" + p(s.p))
+		}
+	}
+	d.WriteString("Z")
+	return d.String()
+}
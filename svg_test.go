@@ -0,0 +1,233 @@
+package myqrcode
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseSVGLogo(t *testing.T) {
+	doc, err := os.ReadFile("logotipo rojo.svg")
+	if err != nil {
+		t.Fatalf("Failed to read logo: %v", err)
+	}
+	logo, err := ParseSVGLogo(doc)
+	if err != nil {
+		t.Fatalf("Failed to parse logo: %v", err)
+	}
+
+	if logo.Bounds() != image.Rect(0, 0, 426, 426) {
+		t.Errorf("Expected the viewBox size rounded up, got %v", logo.Bounds())
+	}
+
+	pink := color.RGBA{0xec, 0x66, 0x69, 255}
+	for _, tc := range []struct {
+		name string
+		x, y int
+		want color.Color
+	}{
+		{"bubble", 60, 205, pink},
+		{"letter stem", 148, 200, color.White},
+		{"hole in the P", 164, 195, pink},
+		{"outside", 20, 40, color.Transparent},
+	} {
+		if got := logo.At(tc.x, tc.y); !sameColor(got, tc.want) {
+			t.Errorf("%s: expected %v at (%d, %d), got %v", tc.name, tc.want, tc.x, tc.y, got)
+		}
+	}
+
+	// Groups, transforms, style attributes, arcs and fill="none"
+	inline := `<svg xmlns="http://www.w3.org/2000/svg" width="10px" height="10px">
+		<g transform="translate(5 5)"><circle r="2" style="fill:#00ff00"/></g>
+		<path d="M0 0A2 2 0 0 0 4 0Z" fill="red"/>
+		<rect x="6" y="0" width="4" height="4" fill="none"/>
+		<defs><rect width="10" height="10"/></defs>
+	</svg>`
+	logo, err = ParseSVGLogo([]byte(inline))
+	if err != nil {
+		t.Fatalf("Failed to parse inline logo: %v", err)
+	}
+	if got := logo.At(5, 5); !sameColor(got, color.RGBA{0, 255, 0, 255}) {
+		t.Errorf("Expected the translated circle at the center, got %v", got)
+	}
+	if got := logo.At(2, 0); !sameColor(got, color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("Expected the arc to bulge downward, got %v", got)
+	}
+	if got := logo.At(8, 2); !sameColor(got, color.Transparent) {
+		t.Errorf("Expected unfilled and defs shapes to stay invisible, got %v", got)
+	}
+
+	if _, err := ParseSVGLogo([]byte(`<html></html>`)); err == nil {
+		t.Error("Expected an error for a document without <svg>")
+	}
+}
+
+func TestSVGLogoPaint(t *testing.T) {
+	doc := `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 40 10">
+		<rect width="10" height="10" fill="rgba(255, 0, 0, 0.5)"/>
+		<rect x="10" width="10" height="10" fill="#0000ff80"/>
+		<rect x="20" width="10" height="10" fill="orange" fill-opacity="1"/>
+		<path d="M30 0h10v10h-10zM33 3h4v4h-4z" fill="black" fill-rule="evenodd"/>
+		<rect width="40" height="10" fill="url(#gradient)"/>
+	</svg>`
+	logo, err := ParseSVGLogo([]byte(doc))
+	if err != nil {
+		t.Fatalf("Failed to parse logo: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		x, y int
+		want color.Color
+	}{
+		{"rgba()", 5, 5, color.NRGBA{255, 0, 0, 128}},
+		{"#rrggbbaa", 15, 5, color.NRGBA{0, 0, 255, 128}},
+		{"named color", 25, 5, color.RGBA{255, 165, 0, 255}},
+		{"even-odd outside the hole", 31, 5, color.Black},
+		{"even-odd hole", 35, 5, color.Transparent},
+	} {
+		if got := logo.At(tc.x, tc.y); !sameColor(got, tc.want) {
+			t.Errorf("%s: expected %v at (%d, %d), got %v", tc.name, tc.want, tc.x, tc.y, got)
+		}
+	}
+
+	// At renders once, whichever goroutine asks first
+	logo, _ = ParseSVGLogo([]byte(doc))
+	done := make(chan color.Color)
+	for range 4 {
+		go func() { done <- logo.At(25, 5) }()
+	}
+	for range 4 {
+		if got := <-done; !sameColor(got, color.RGBA{255, 165, 0, 255}) {
+			t.Errorf("Expected orange from a concurrent At, got %v", got)
+		}
+	}
+
+	for _, fill := range []string{"blurple", "#12345", "rgb(1, 2)", "rgba(1, 2, 3, x)", "hsl(0, 100%, 50%)"} {
+		doc := `<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"><rect width="10" height="10" fill="` + fill + `"/></svg>`
+		if _, err := ParseSVGLogo([]byte(doc)); err == nil {
+			t.Errorf("Expected an error for fill %q", fill)
+		}
+	}
+}
+
+func TestSVGLogoReadability(t *testing.T) {
+	doc, err := os.ReadFile("logotipo rojo.svg")
+	if err != nil {
+		t.Fatalf("Failed to read logo: %v", err)
+	}
+	logo, err := ParseSVGLogo(doc)
+	if err != nil {
+		t.Fatalf("Failed to parse logo: %v", err)
+	}
+
+	qr, err := New("https://meet.google.com/abc-defg-hij", High)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	qr.SetLogo(logo, 30)
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	img, err := qr.ToImage(StyleConfig{ModuleSize: 10, QuietZone: 40})
+	if err != nil {
+		t.Fatalf("Failed to generate image: %v", err)
+	}
+
+	// The bubble is drawn sharp in the middle of the logo area
+	placement := qr.logoOverlay(logo, qr.LogoSize).placement(qr.Version)
+	x := 40 + placement.X*10 + placement.Width*10*60/425
+	y := 40 + placement.Y*10 + placement.Height*10*205/425
+	if got := img.At(x, y); !sameColor(got, color.RGBA{0xec, 0x66, 0x69, 255}) {
+		t.Errorf("Expected the logo's pink at (%d, %d), got %v", x, y, got)
+	}
+
+	saveTestImage(t, img, "test_svg_logo.png")
+}
+
+func TestToSVG(t *testing.T) {
+	doc, err := os.ReadFile("logotipo rojo.svg")
+	if err != nil {
+		t.Fatalf("Failed to read logo: %v", err)
+	}
+	logo, err := ParseSVGLogo(doc)
+	if err != nil {
+		t.Fatalf("Failed to parse logo: %v", err)
+	}
+
+	qr, err := New("https://example.com", High)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	qr.SetLogo(logo, 25)
+	qr.SetLogoStyle(LogoStyle{Shape: LogoCircle, Padding: 4, BorderWidth: 2})
+	qr.AddOverlay(Overlay{Image: createTestLogo(40), Size: 10, Anchor: AnchorBottomRight})
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	out, err := qr.ToSVG(StyleConfig{ModuleSize: 10})
+	if err != nil {
+		t.Fatalf("Failed to generate SVG: %v", err)
+	}
+
+	// The document must be well-formed
+	decoder := xml.NewDecoder(bytes.NewReader(out))
+	elements := make(map[string]int)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Invalid SVG output: %v", err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			elements[start.Name.Local]++
+		}
+	}
+
+	size := qr.Size*10 + 80
+	if !strings.HasPrefix(string(out), `<svg xmlns="http://www.w3.org/2000/svg" width="`) ||
+		!strings.Contains(string(out), `viewBox="0 0 `+strconv.Itoa(size)+" "+strconv.Itoa(size)+`"`) {
+		t.Errorf("Unexpected SVG header: %.120s", out)
+	}
+	if elements["image"] != 2 || elements["clipPath"] != 1 {
+		t.Errorf("Expected two images and one clip path, got %v", elements)
+	}
+	if !strings.Contains(string(out), "data:image/svg+xml;base64,") {
+		t.Error("Expected the SVG logo to be embedded as a vector document")
+	}
+	if !strings.Contains(string(out), "data:image/png;base64,") {
+		t.Error("Expected the bitmap overlay to be embedded as a PNG")
+	}
+
+	// Dark alignment modules under the badge are drawn after the overlays,
+	// and the light ones leave the badge showing
+	var placements []LogoPlacement
+	for _, o := range qr.overlays() {
+		placements = append(placements, o.placement(qr.Version))
+	}
+	badge := strings.LastIndex(string(out), "data:image/png;base64,")
+	for _, m := range qr.alignmentUnder(placements) {
+		module := fmt.Sprintf("M%d %dh10v10h-10z", 40+m.X*10, 40+m.Y*10)
+		at := strings.LastIndex(string(out), module)
+		if qr.Matrix[m.Y][m.X] && at < badge {
+			t.Errorf("Expected alignment module (%d, %d) after the badge", m.X, m.Y)
+		}
+		if !qr.Matrix[m.Y][m.X] && at > badge {
+			t.Errorf("Expected light alignment module (%d, %d) not to be drawn over the badge", m.X, m.Y)
+		}
+	}
+
+	os.MkdirAll("test_output", 0755)
+	os.WriteFile("test_output/test_svg_output.svg", out, 0644)
+}
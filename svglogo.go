@@ -0,0 +1,851 @@
+package myqrcode
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/vector"
+)
+
+// SVGLogo is a vector logo parsed from an SVG document. It is an
+// image.Image, so it can be passed to SetLogo or used as an overlay: ToSVG
+// embeds the original document, and ToImage rasterizes its paths at exactly
+// the size they are drawn.
+//
+// Only the basic shapes are understood: paths, rects, circles, ellipses and
+// polygons, grouped and transformed, filled with flat colors from
+// attributes, style attributes or class rules, with either fill rule.
+// Shapes painted with a url() reference, such as gradients, are skipped;
+// any other paint that cannot be parsed is an error.
+type SVGLogo struct {
+	document []byte
+	viewBox  [4]float64 // Min x, min y, width, height
+	shapes   []svgShape
+
+	rasterOnce sync.Once
+	raster     *image.RGBA // Native size rendering for At
+}
+
+// svgShape is one filled element, in viewBox coordinates
+type svgShape struct {
+	outline []*contour
+	fill    color.Color
+	evenOdd bool
+}
+
+// svgMatrix is an affine transform [a b c d e f]
+type svgMatrix [6]float64
+
+var svgIdentity = svgMatrix{1, 0, 0, 1, 0, 0}
+
+func (m svgMatrix) mul(n svgMatrix) svgMatrix {
+	return svgMatrix{
+		m[0]*n[0] + m[2]*n[1], m[1]*n[0] + m[3]*n[1],
+		m[0]*n[2] + m[2]*n[3], m[1]*n[2] + m[3]*n[3],
+		m[0]*n[4] + m[2]*n[5] + m[4], m[1]*n[4] + m[3]*n[5] + m[5],
+	}
+}
+
+func (m svgMatrix) apply(x, y float64) point {
+	return point{float32(m[0]*x + m[2]*y + m[4]), float32(m[1]*x + m[3]*y + m[5])}
+}
+
+// svgState holds the inherited presentation properties of an element
+type svgState struct {
+	fill      color.Color // nil: none
+	evenOdd   bool        // fill-rule="evenodd"
+	opacity   float64     // Group and fill opacity, multiplied down the tree
+	transform svgMatrix
+	hidden    bool // Inside defs, clip paths and the like
+}
+
+// ParseSVGLogo parses an SVG document into a logo
+func ParseSVGLogo(document []byte) (*SVGLogo, error) {
+	logo := &SVGLogo{document: document}
+	classes := svgClassRules(document)
+
+	decoder := xml.NewDecoder(bytes.NewReader(document))
+	decoder.Strict = false
+	stack := []svgState{{fill: color.Black, opacity: 1, transform: svgIdentity}}
+	root := true
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid SVG logo: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			attrs := make(map[string]string)
+			for _, a := range t.Attr {
+				attrs[a.Name.Local] = a.Value
+			}
+
+			if root {
+				if t.Name.Local != "svg" {
+					return nil, errors.New("invalid SVG logo: root element is not <svg>")
+				}
+				if err := logo.parseViewBox(attrs); err != nil {
+					return nil, err
+				}
+				root = false
+			}
+
+			state, err := stack[len(stack)-1].inherit(attrs, classes)
+			if err != nil {
+				return nil, err
+			}
+			switch t.Name.Local {
+			case "defs", "clipPath", "mask", "symbol", "pattern", "marker", "style", "title", "desc", "metadata":
+				state.hidden = true
+			}
+			stack = append(stack, state)
+
+			if !state.hidden && state.fill != nil {
+				if outline := svgElementOutline(t.Name.Local, attrs, state.transform); len(outline) > 0 {
+					logo.shapes = append(logo.shapes, svgShape{outline: outline, fill: scaleAlpha(state.fill, state.opacity), evenOdd: state.evenOdd})
+				}
+			}
+
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+
+	if root {
+		return nil, errors.New("invalid SVG logo: no <svg> element")
+	}
+	return logo, nil
+}
+
+func (s *SVGLogo) parseViewBox(attrs map[string]string) error {
+	if box := svgNumbers(attrs["viewBox"]); len(box) == 4 && box[2] > 0 && box[3] > 0 {
+		copy(s.viewBox[:], box)
+		return nil
+	}
+	w, h := svgLength(attrs["width"]), svgLength(attrs["height"])
+	if w <= 0 || h <= 0 {
+		return errors.New("invalid SVG logo: no viewBox or size")
+	}
+	s.viewBox = [4]float64{0, 0, w, h}
+	return nil
+}
+
+func (s *SVGLogo) ColorModel() color.Model {
+	return color.RGBAModel
+}
+
+// Bounds returns the viewBox size rounded up to whole pixels
+func (s *SVGLogo) Bounds() image.Rectangle {
+	return image.Rect(0, 0, int(math.Ceil(s.viewBox[2])), int(math.Ceil(s.viewBox[3])))
+}
+
+func (s *SVGLogo) At(x, y int) color.Color {
+	s.rasterOnce.Do(func() {
+		s.raster = image.NewRGBA(s.Bounds())
+		s.draw(s.raster, s.raster.Bounds())
+	})
+	return s.raster.At(x, y)
+}
+
+// Document returns the SVG document the logo was parsed from
+func (s *SVGLogo) Document() []byte {
+	return s.document
+}
+
+// draw renders the logo stretched onto r, compositing over dst
+func (s *SVGLogo) draw(dst *image.RGBA, r image.Rectangle) {
+	if r.Empty() {
+		return
+	}
+	sx := float32(float64(r.Dx()) / s.viewBox[2])
+	sy := float32(float64(r.Dy()) / s.viewBox[3])
+	ox, oy := float32(s.viewBox[0]), float32(s.viewBox[1])
+	place := func(p point) point { return point{(p.X - ox) * sx, (p.Y - oy) * sy} }
+
+	for _, shape := range s.shapes {
+		outline := make([]*contour, len(shape.outline))
+		for i, c := range shape.outline {
+			outline[i] = c.mapped(place)
+		}
+		if shape.evenOdd {
+			drawEvenOdd(dst, r, shape.fill, outline)
+		} else {
+			drawContours(dst, r, shape.fill, QualityExact, outline...)
+		}
+	}
+}
+
+// drawEvenOdd fills contours with the even-odd rule, which the rasterizer
+// lacks: each subpath is rasterized on its own and the coverages are
+// combined as an exclusive or, so overlapping subpaths cut holes whichever
+// way they wind
+func drawEvenOdd(dst *image.RGBA, r image.Rectangle, c color.Color, contours []*contour) {
+	mask := image.NewAlpha(image.Rect(0, 0, r.Dx(), r.Dy()))
+	single := image.NewAlpha(mask.Bounds())
+	for _, ct := range contours {
+		clear(single.Pix)
+		z := vector.NewRasterizer(r.Dx(), r.Dy())
+		ct.addTo(z, false)
+		z.Draw(single, single.Bounds(), image.Opaque, image.Point{})
+		for i, b := range single.Pix {
+			a := int(mask.Pix[i])
+			mask.Pix[i] = uint8((a*(255-int(b)) + int(b)*(255-a) + 127) / 255)
+		}
+	}
+	draw.DrawMask(dst, r, &image.Uniform{c}, image.Point{}, mask, image.Point{}, draw.Over)
+}
+
+// inherit applies an element's presentation attributes, class rules and
+// style attribute, in increasing priority, on top of the parent's state
+func (s svgState) inherit(attrs map[string]string, classes map[string]map[string]string) (svgState, error) {
+	props := make(map[string]string)
+	for _, name := range []string{"fill", "fill-rule", "opacity", "fill-opacity", "display", "visibility"} {
+		if v, ok := attrs[name]; ok {
+			props[name] = v
+		}
+	}
+	for _, class := range strings.Fields(attrs["class"]) {
+		for k, v := range classes[class] {
+			props[k] = v
+		}
+	}
+	for k, v := range svgDeclarations(attrs["style"]) {
+		props[k] = v
+	}
+
+	if v, ok := props["fill"]; ok && strings.TrimSpace(v) != "inherit" {
+		fill, err := svgPaint(v)
+		if err != nil {
+			return s, err
+		}
+		s.fill = fill
+	}
+	if v, ok := props["fill-rule"]; ok {
+		switch strings.TrimSpace(v) {
+		case "evenodd":
+			s.evenOdd = true
+		case "nonzero":
+			s.evenOdd = false
+		}
+	}
+	if v, ok := props["display"]; ok && strings.TrimSpace(v) == "none" {
+		s.hidden = true
+	}
+	if v, ok := props["visibility"]; ok && strings.TrimSpace(v) == "hidden" {
+		s.hidden = true
+	}
+
+	for _, name := range []string{"opacity", "fill-opacity"} {
+		if v, err := strconv.ParseFloat(strings.TrimSpace(props[name]), 64); err == nil {
+			s.opacity *= math.Max(0, math.Min(1, v))
+		}
+	}
+
+	if t, ok := attrs["transform"]; ok {
+		s.transform = s.transform.mul(svgTransform(t))
+	}
+	return s, nil
+}
+
+var svgRulePattern = regexp.MustCompile(`([^{}]+)\{([^}]*)\}`)
+
+// svgClassRules collects the declarations of ".name" rules in <style> elements
+func svgClassRules(document []byte) map[string]map[string]string {
+	rules := make(map[string]map[string]string)
+	decoder := xml.NewDecoder(bytes.NewReader(document))
+	decoder.Strict = false
+
+	inStyle := false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			inStyle = t.Name.Local == "style"
+		case xml.EndElement:
+			inStyle = false
+		case xml.CharData:
+			if !inStyle {
+				continue
+			}
+			for _, m := range svgRulePattern.FindAllStringSubmatch(string(t), -1) {
+				declarations := svgDeclarations(m[2])
+				for _, selector := range strings.Split(m[1], ",") {
+					selector = strings.TrimSpace(selector)
+					if !strings.HasPrefix(selector, ".") {
+						continue
+					}
+					class := selector[1:]
+					if rules[class] == nil {
+						rules[class] = make(map[string]string)
+					}
+					for k, v := range declarations {
+						rules[class][k] = v
+					}
+				}
+			}
+		}
+	}
+	return rules
+}
+
+// svgDeclarations parses "name: value; ..." pairs
+func svgDeclarations(s string) map[string]string {
+	declarations := make(map[string]string)
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, ":")
+		if ok {
+			declarations[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+	}
+	return declarations
+}
+
+// svgNamedColors holds the CSS color keywords as 0xRRGGBB
+var svgNamedColors = map[string]uint32{
+	"aliceblue": 0xf0f8ff, "antiquewhite": 0xfaebd7, "aqua": 0x00ffff,
+	"aquamarine": 0x7fffd4, "azure": 0xf0ffff, "beige": 0xf5f5dc,
+	"bisque": 0xffe4c4, "black": 0x000000, "blanchedalmond": 0xffebcd,
+	"blue": 0x0000ff, "blueviolet": 0x8a2be2, "brown": 0xa52a2a,
+	"burlywood": 0xdeb887, "cadetblue": 0x5f9ea0, "chartreuse": 0x7fff00,
+	"chocolate": 0xd2691e, "coral": 0xff7f50, "cornflowerblue": 0x6495ed,
+	"cornsilk": 0xfff8dc, "crimson": 0xdc143c, "cyan": 0x00ffff,
+	"darkblue": 0x00008b, "darkcyan": 0x008b8b, "darkgoldenrod": 0xb8860b,
+	"darkgray": 0xa9a9a9, "darkgreen": 0x006400, "darkgrey": 0xa9a9a9,
+	"darkkhaki": 0xbdb76b, "darkmagenta": 0x8b008b, "darkolivegreen": 0x556b2f,
+	"darkorange": 0xff8c00, "darkorchid": 0x9932cc, "darkred": 0x8b0000,
+	"darksalmon": 0xe9967a, "darkseagreen": 0x8fbc8f,
+	"darkslateblue": 0x483d8b, "darkslategray": 0x2f4f4f,
+	"darkslategrey": 0x2f4f4f, "darkturquoise": 0x00ced1,
+	"darkviolet": 0x9400d3, "deeppink": 0xff1493, "deepskyblue": 0x00bfff,
+	"dimgray": 0x696969, "dimgrey": 0x696969, "dodgerblue": 0x1e90ff,
+	"firebrick": 0xb22222, "floralwhite": 0xfffaf0, "forestgreen": 0x228b22,
+	"fuchsia": 0xff00ff, "gainsboro": 0xdcdcdc, "ghostwhite": 0xf8f8ff,
+	"gold": 0xffd700, "goldenrod": 0xdaa520, "gray": 0x808080,
+	"green": 0x008000, "greenyellow": 0xadff2f, "grey": 0x808080,
+	"honeydew": 0xf0fff0, "hotpink": 0xff69b4, "indianred": 0xcd5c5c,
+	"indigo": 0x4b0082, "ivory": 0xfffff0, "khaki": 0xf0e68c,
+	"lavender": 0xe6e6fa, "lavenderblush": 0xfff0f5, "lawngreen": 0x7cfc00,
+	"lemonchiffon": 0xfffacd, "lightblue": 0xadd8e6, "lightcoral": 0xf08080,
+	"lightcyan": 0xe0ffff, "lightgoldenrodyellow": 0xfafad2,
+	"lightgray": 0xd3d3d3, "lightgreen": 0x90ee90, "lightgrey": 0xd3d3d3,
+	"lightpink": 0xffb6c1, "lightsalmon": 0xffa07a, "lightseagreen": 0x20b2aa,
+	"lightskyblue": 0x87cefa, "lightslategray": 0x778899,
+	"lightslategrey": 0x778899, "lightsteelblue": 0xb0c4de,
+	"lightyellow": 0xffffe0, "lime": 0x00ff00, "limegreen": 0x32cd32,
+	"linen": 0xfaf0e6, "magenta": 0xff00ff, "maroon": 0x800000,
+	"mediumaquamarine": 0x66cdaa, "mediumblue": 0x0000cd,
+	"mediumorchid": 0xba55d3, "mediumpurple": 0x9370db,
+	"mediumseagreen": 0x3cb371, "mediumslateblue": 0x7b68ee,
+	"mediumspringgreen": 0x00fa9a, "mediumturquoise": 0x48d1cc,
+	"mediumvioletred": 0xc71585, "midnightblue": 0x191970,
+	"mintcream": 0xf5fffa, "mistyrose": 0xffe4e1, "moccasin": 0xffe4b5,
+	"navajowhite": 0xffdead, "navy": 0x000080, "oldlace": 0xfdf5e6,
+	"olive": 0x808000, "olivedrab": 0x6b8e23, "orange": 0xffa500,
+	"orangered": 0xff4500, "orchid": 0xda70d6, "palegoldenrod": 0xeee8aa,
+	"palegreen": 0x98fb98, "paleturquoise": 0xafeeee,
+	"palevioletred": 0xdb7093, "papayawhip": 0xffefd5, "peachpuff": 0xffdab9,
+	"peru": 0xcd853f, "pink": 0xffc0cb, "plum": 0xdda0dd,
+	"powderblue": 0xb0e0e6, "purple": 0x800080, "rebeccapurple": 0x663399,
+	"red": 0xff0000, "rosybrown": 0xbc8f8f, "royalblue": 0x4169e1,
+	"saddlebrown": 0x8b4513, "salmon": 0xfa8072, "sandybrown": 0xf4a460,
+	"seagreen": 0x2e8b57, "seashell": 0xfff5ee, "sienna": 0xa0522d,
+	"silver": 0xc0c0c0, "skyblue": 0x87ceeb, "slateblue": 0x6a5acd,
+	"slategray": 0x708090, "slategrey": 0x708090, "snow": 0xfffafa,
+	"springgreen": 0x00ff7f, "steelblue": 0x4682b4, "tan": 0xd2b48c,
+	"teal": 0x008080, "thistle": 0xd8bfd8, "tomato": 0xff6347,
+	"turquoise": 0x40e0d0, "violet": 0xee82ee, "wheat": 0xf5deb3,
+	"white": 0xffffff, "whitesmoke": 0xf5f5f5, "yellow": 0xffff00,
+	"yellowgreen": 0x9acd32,
+}
+
+// svgPaint parses a fill value. It returns nil for paint that draws nothing
+// and for url() references, which are not supported and skipped.
+func svgPaint(v string) (color.Color, error) {
+	v = strings.ToLower(strings.TrimSpace(v))
+	invalid := fmt.Errorf("invalid SVG logo: unsupported fill %q", v)
+	switch {
+	case v == "none" || v == "transparent" || strings.HasPrefix(v, "url("):
+		return nil, nil
+	case v == "currentcolor":
+		return color.Black, nil
+	case strings.HasPrefix(v, "#"):
+		hex := v[1:]
+		if len(hex) == 3 || len(hex) == 4 {
+			long := make([]byte, 0, 8)
+			for i := range len(hex) {
+				long = append(long, hex[i], hex[i])
+			}
+			hex = string(long)
+		}
+		if len(hex) == 6 {
+			hex += "ff"
+		}
+		n, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) != 8 {
+			return nil, invalid
+		}
+		return color.NRGBA{uint8(n >> 24), uint8(n >> 16), uint8(n >> 8), uint8(n)}, nil
+	case strings.HasPrefix(v, "rgb(") || strings.HasPrefix(v, "rgba("):
+		open := strings.IndexByte(v, '(')
+		if !strings.HasSuffix(v, ")") {
+			return nil, invalid
+		}
+		parts := strings.FieldsFunc(v[open+1:len(v)-1], func(r rune) bool { return r == ',' || r == ' ' || r == '/' })
+		if len(parts) != 3 && len(parts) != 4 {
+			return nil, invalid
+		}
+		c := [4]uint8{3: 255}
+		for i, part := range parts {
+			scale := 1.0
+			if i == 3 {
+				scale = 255 // Alpha is a fraction
+			}
+			if strings.HasSuffix(part, "%") {
+				part, scale = strings.TrimSuffix(part, "%"), 2.55
+			}
+			f, err := strconv.ParseFloat(part, 64)
+			if err != nil {
+				return nil, invalid
+			}
+			c[i] = uint8(math.Max(0, math.Min(255, f*scale)) + 0.5)
+		}
+		return color.NRGBA{c[0], c[1], c[2], c[3]}, nil
+	}
+	n, ok := svgNamedColors[v]
+	if !ok {
+		return nil, invalid
+	}
+	return color.RGBA{uint8(n >> 16), uint8(n >> 8), uint8(n), 255}, nil
+}
+
+var svgNumberPattern = regexp.MustCompile(`[+-]?(?:\d+\.?\d*|\.\d+)(?:[eE][+-]?\d+)?`)
+
+// svgNumbers returns every number in s
+func svgNumbers(s string) []float64 {
+	var numbers []float64
+	for _, m := range svgNumberPattern.FindAllString(s, -1) {
+		f, _ := strconv.ParseFloat(m, 64)
+		numbers = append(numbers, f)
+	}
+	return numbers
+}
+
+// svgLength parses a length such as "120" or "120px"; other units are not supported
+func svgLength(s string) float64 {
+	f, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "px"), 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
+
+var svgTransformPattern = regexp.MustCompile(`(\w+)\s*\(([^)]*)\)`)
+
+// svgTransform parses a transform list into one matrix
+func svgTransform(s string) svgMatrix {
+	m := svgIdentity
+	for _, match := range svgTransformPattern.FindAllStringSubmatch(s, -1) {
+		args := svgNumbers(match[2])
+		arg := func(i int, fallback float64) float64 {
+			if i < len(args) {
+				return args[i]
+			}
+			return fallback
+		}
+
+		var t svgMatrix
+		switch match[1] {
+		case "matrix":
+			if len(args) != 6 {
+				continue
+			}
+			copy(t[:], args)
+		case "translate":
+			t = svgMatrix{1, 0, 0, 1, arg(0, 0), arg(1, 0)}
+		case "scale":
+			sx := arg(0, 1)
+			t = svgMatrix{sx, 0, 0, arg(1, sx), 0, 0}
+		case "rotate":
+			sin, cos := math.Sincos(arg(0, 0) * math.Pi / 180)
+			cx, cy := arg(1, 0), arg(2, 0)
+			t = svgMatrix{1, 0, 0, 1, cx, cy}.mul(svgMatrix{cos, sin, -sin, cos, 0, 0}).mul(svgMatrix{1, 0, 0, 1, -cx, -cy})
+		case "skewX":
+			t = svgMatrix{1, 0, math.Tan(arg(0, 0) * math.Pi / 180), 1, 0, 0}
+		case "skewY":
+			t = svgMatrix{1, math.Tan(arg(0, 0) * math.Pi / 180), 0, 1, 0, 0}
+		default:
+			continue
+		}
+		m = m.mul(t)
+	}
+	return m
+}
+
+// svgElementOutline builds the outline of a basic shape element
+func svgElementOutline(name string, attrs map[string]string, m svgMatrix) []*contour {
+	num := func(key string) float64 { return svgLength(attrs[key]) }
+	b := &svgPathBuilder{m: m}
+
+	switch name {
+	case "path":
+		b.parse(attrs["d"])
+	case "rect":
+		x, y, w, h := num("x"), num("y"), num("width"), num("height")
+		if w <= 0 || h <= 0 {
+			return nil
+		}
+		rx, ry := num("rx"), num("ry")
+		if rx == 0 {
+			rx = ry
+		}
+		if ry == 0 {
+			ry = rx
+		}
+		rx, ry = math.Min(rx, w/2), math.Min(ry, h/2)
+		b.moveTo(x+rx, y)
+		b.lineTo(x+w-rx, y)
+		b.arcTo(rx, ry, x+w, y+ry)
+		b.lineTo(x+w, y+h-ry)
+		b.arcTo(rx, ry, x+w-rx, y+h)
+		b.lineTo(x+rx, y+h)
+		b.arcTo(rx, ry, x, y+h-ry)
+		b.lineTo(x, y+ry)
+		b.arcTo(rx, ry, x+rx, y)
+	case "circle", "ellipse":
+		cx, cy := num("cx"), num("cy")
+		rx, ry := num("rx"), num("ry")
+		if name == "circle" {
+			rx, ry = num("r"), num("r")
+		}
+		if rx <= 0 || ry <= 0 {
+			return nil
+		}
+		b.moveTo(cx+rx, cy)
+		b.arcTo(rx, ry, cx, cy+ry)
+		b.arcTo(rx, ry, cx-rx, cy)
+		b.arcTo(rx, ry, cx, cy-ry)
+		b.arcTo(rx, ry, cx+rx, cy)
+	case "polygon", "polyline":
+		points := svgNumbers(attrs["points"])
+		for i := 0; i+1 < len(points); i += 2 {
+			if i == 0 {
+				b.moveTo(points[0], points[1])
+			} else {
+				b.lineTo(points[i], points[i+1])
+			}
+		}
+	}
+
+	return b.contours
+}
+
+// svgPathBuilder turns path commands into contours in transformed coordinates
+type svgPathBuilder struct {
+	m        svgMatrix
+	contours []*contour
+	current  *contour
+
+	x, y        float64 // Current point
+	startX      float64 // Start of the subpath
+	startY      float64
+	ctrlX       float64 // Last control point, for smooth curves
+	ctrlY       float64
+	lastCommand byte
+}
+
+func (b *svgPathBuilder) moveTo(x, y float64) {
+	b.current = &contour{start: b.m.apply(x, y)}
+	b.contours = append(b.contours, b.current)
+	b.x, b.y, b.startX, b.startY = x, y, x, y
+}
+
+func (b *svgPathBuilder) ensure() {
+	if b.current == nil {
+		b.moveTo(b.x, b.y)
+	}
+}
+
+func (b *svgPathBuilder) lineTo(x, y float64) {
+	b.ensure()
+	p := b.m.apply(x, y)
+	b.current.lineTo(p.X, p.Y)
+	b.x, b.y = x, y
+}
+
+func (b *svgPathBuilder) cubeTo(c1x, c1y, c2x, c2y, x, y float64) {
+	b.ensure()
+	c1, c2, p := b.m.apply(c1x, c1y), b.m.apply(c2x, c2y), b.m.apply(x, y)
+	b.current.cubeTo(c1.X, c1.Y, c2.X, c2.Y, p.X, p.Y)
+	b.ctrlX, b.ctrlY = c2x, c2y
+	b.x, b.y = x, y
+}
+
+func (b *svgPathBuilder) quadTo(cx, cy, x, y float64) {
+	// Raise the quadratic to a cubic
+	b.cubeTo(b.x+2.0/3*(cx-b.x), b.y+2.0/3*(cy-b.y), x+2.0/3*(cx-x), y+2.0/3*(cy-y), x, y)
+	b.ctrlX, b.ctrlY = cx, cy
+}
+
+// arcTo draws a quarter ellipse from the current point to (x, y), bulging
+// outward the way rounded corners and ellipses are traced clockwise
+func (b *svgPathBuilder) arcTo(rx, ry, x, y float64) {
+	if rx == 0 || ry == 0 {
+		b.lineTo(x, y)
+		return
+	}
+	b.arc(rx, ry, 0, false, true, x, y)
+}
+
+// arc draws an elliptical arc as in the SVG "A" command, converted to cubic
+// Béziers of at most a quarter turn each (SVG 1.1, appendix F.6)
+func (b *svgPathBuilder) arc(rx, ry, rotation float64, large, sweep bool, x, y float64) {
+	x1, y1 := b.x, b.y
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 || (x1 == x && y1 == y) {
+		b.lineTo(x, y)
+		return
+	}
+
+	sinPhi, cosPhi := math.Sincos(rotation * math.Pi / 180)
+	dx, dy := (x1-x)/2, (y1-y)/2
+	px := cosPhi*dx + sinPhi*dy
+	py := -sinPhi*dx + cosPhi*dy
+
+	// Scale up radii that cannot span the endpoints
+	if lambda := px*px/(rx*rx) + py*py/(ry*ry); lambda > 1 {
+		s := math.Sqrt(lambda)
+		rx, ry = rx*s, ry*s
+	}
+
+	num := rx*rx*ry*ry - rx*rx*py*py - ry*ry*px*px
+	den := rx*rx*py*py + ry*ry*px*px
+	coef := math.Sqrt(math.Max(0, num/den))
+	if large == sweep {
+		coef = -coef
+	}
+	cxp, cyp := coef*rx*py/ry, -coef*ry*px/rx
+	cx := cosPhi*cxp - sinPhi*cyp + (x1+x)/2
+	cy := sinPhi*cxp + cosPhi*cyp + (y1+y)/2
+
+	angle := func(ux, uy, vx, vy float64) float64 {
+		return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+	}
+	theta := angle(1, 0, (px-cxp)/rx, (py-cyp)/ry)
+	delta := angle((px-cxp)/rx, (py-cyp)/ry, (-px-cxp)/rx, (-py-cyp)/ry)
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+
+	segments := int(math.Ceil(math.Abs(delta) / (math.Pi / 2)))
+	step := delta / float64(segments)
+	k := 4.0 / 3 * math.Tan(step/4)
+	at := func(t float64) (float64, float64, float64, float64) {
+		sin, cos := math.Sincos(t)
+		ex, ey := rx*cos, ry*sin  // Point on the ellipse
+		tx, ty := -rx*sin, ry*cos // Tangent
+		return cosPhi*ex - sinPhi*ey + cx, sinPhi*ex + cosPhi*ey + cy,
+			cosPhi*tx - sinPhi*ty, sinPhi*tx + cosPhi*ty
+	}
+	for i := 0; i < segments; i++ {
+		t0, t1 := theta+float64(i)*step, theta+float64(i+1)*step
+		ax, ay, atx, aty := at(t0)
+		bx, by, btx, bty := at(t1)
+		if i == segments-1 {
+			bx, by = x, y
+		}
+		b.cubeTo(ax+k*atx, ay+k*aty, bx-k*btx, by-k*bty, bx, by)
+	}
+}
+
+// parse reads path data
+func (b *svgPathBuilder) parse(d string) {
+	s := &svgScanner{s: d}
+	command := byte(0)
+	for {
+		s.skip()
+		if s.done() {
+			return
+		}
+		if c := s.s[s.i]; strings.IndexByte("MmLlHhVvCcSsQqTtAaZz", c) >= 0 {
+			command = c
+			s.i++
+		} else if command == 0 {
+			return // Numbers before any command
+		}
+
+		rel := command >= 'a'
+		ox, oy := 0.0, 0.0
+		if rel {
+			ox, oy = b.x, b.y
+		}
+
+		var ok bool
+		switch command | 0x20 {
+		case 'z':
+			b.lineTo(b.startX, b.startY)
+			b.current = nil
+			b.lastCommand = command
+			continue
+		case 'm':
+			var x, y float64
+			if x, y, ok = s.pair(); ok {
+				b.moveTo(ox+x, oy+y)
+				// Further pairs are implicit line commands
+				if rel {
+					command = 'l'
+				} else {
+					command = 'L'
+				}
+			}
+		case 'l':
+			var x, y float64
+			if x, y, ok = s.pair(); ok {
+				b.lineTo(ox+x, oy+y)
+			}
+		case 'h':
+			var x float64
+			if x, ok = s.number(); ok {
+				b.lineTo(ox+x, b.y)
+			}
+		case 'v':
+			var y float64
+			if y, ok = s.number(); ok {
+				b.lineTo(b.x, oy+y)
+			}
+		case 'c':
+			var v [6]float64
+			if ok = s.numbers(v[:]); ok {
+				b.cubeTo(ox+v[0], oy+v[1], ox+v[2], oy+v[3], ox+v[4], oy+v[5])
+			}
+		case 's':
+			var v [4]float64
+			if ok = s.numbers(v[:]); ok {
+				c1x, c1y := b.x, b.y
+				if strings.IndexByte("CcSs", b.lastCommand) >= 0 {
+					c1x, c1y = 2*b.x-b.ctrlX, 2*b.y-b.ctrlY
+				}
+				b.cubeTo(c1x, c1y, ox+v[0], oy+v[1], ox+v[2], oy+v[3])
+			}
+		case 'q':
+			var v [4]float64
+			if ok = s.numbers(v[:]); ok {
+				b.quadTo(ox+v[0], oy+v[1], ox+v[2], oy+v[3])
+			}
+		case 't':
+			var x, y float64
+			if x, y, ok = s.pair(); ok {
+				cx, cy := b.x, b.y
+				if strings.IndexByte("QqTt", b.lastCommand) >= 0 {
+					cx, cy = 2*b.x-b.ctrlX, 2*b.y-b.ctrlY
+				}
+				b.quadTo(cx, cy, ox+x, oy+y)
+			}
+		case 'a':
+			var v [3]float64
+			var large, sweep bool
+			var x, y float64
+			if ok = s.numbers(v[:]); ok {
+				if large, ok = s.flag(); ok {
+					if sweep, ok = s.flag(); ok {
+						if x, y, ok = s.pair(); ok {
+							b.arc(v[0], v[1], v[2], large, sweep, ox+x, oy+y)
+						}
+					}
+				}
+			}
+		}
+		if !ok {
+			return // Malformed data: keep what was drawn so far
+		}
+		b.lastCommand = command
+	}
+}
+
+// svgScanner reads numbers and flags from path data
+type svgScanner struct {
+	s string
+	i int
+}
+
+func (s *svgScanner) skip() {
+	for s.i < len(s.s) && strings.IndexByte(" \t\r\n,", s.s[s.i]) >= 0 {
+		s.i++
+	}
+}
+
+func (s *svgScanner) done() bool {
+	return s.i >= len(s.s)
+}
+
+func (s *svgScanner) number() (float64, bool) {
+	s.skip()
+	loc := svgNumberPattern.FindStringIndex(s.s[s.i:])
+	if loc == nil || loc[0] != 0 {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(s.s[s.i:s.i+loc[1]], 64)
+	s.i += loc[1]
+	return f, err == nil
+}
+
+func (s *svgScanner) pair() (float64, float64, bool) {
+	x, ok := s.number()
+	if !ok {
+		return 0, 0, false
+	}
+	y, ok := s.number()
+	return x, y, ok
+}
+
+func (s *svgScanner) numbers(v []float64) bool {
+	for i := range v {
+		var ok bool
+		if v[i], ok = s.number(); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// flag reads an arc flag, which may be written without a separator
+func (s *svgScanner) flag() (bool, bool) {
+	s.skip()
+	if s.done() || (s.s[s.i] != '0' && s.s[s.i] != '1') {
+		return false, false
+	}
+	s.i++
+	return s.s[s.i-1] == '1', true
+}
+
+// scaleAlpha scales the alpha of c by opacity
+func scaleAlpha(c color.Color, opacity float64) color.Color {
+	if opacity >= 1 {
+		return c
+	}
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	n.A = uint8(float64(n.A)*opacity + 0.5)
+	return n
+}
@@ -0,0 +1,80 @@
+package myqrcode
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestTransparentBackground(t *testing.T) {
+	qr, err := New("https://meet.google.com/abc-defg-hij", Medium)
+	if err != nil {
+		t.Fatalf("Failed to create QR code: %v", err)
+	}
+	if err := qr.Encode(); err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+
+	blue := color.NRGBA{20, 40, 200, 255}
+	drawers := map[string]ModuleDrawer{
+		"square":  NewSquareModuleDrawer(),
+		"circle":  NewCircleModuleDrawer(),
+		"gapped":  NewGappedCircleModuleDrawer(0.8),
+		"rounded": NewRoundedModuleDrawer(1.0),
+	}
+
+	for name, drawer := range drawers {
+		config := DefaultStyleConfig()
+		config.ModuleSize = 10
+		config.QuietZone = 40
+		config.ModuleDrawer = drawer
+		config.FinderDrawer = NewFinderDrawer(EyeRounded, EyeCircle)
+		config.ForegroundColor = blue
+		WithTransparentBackground()(&config)
+
+		img, err := qr.ToImage(config)
+		if err != nil {
+			t.Fatalf("%s: failed to generate image: %v", name, err)
+		}
+
+		if _, _, _, a := img.At(0, 0).RGBA(); a != 0 {
+			t.Errorf("%s: expected transparent quiet zone, alpha %d", name, a)
+		}
+
+		// Every pixel is the foreground color at some coverage: anti-aliased
+		// edges fade out instead of blending toward a baked-in background
+		partial := 0
+		bounds := img.Bounds()
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+				if c.A == 0 {
+					continue
+				}
+				if c.A < 255 {
+					partial++
+				}
+				if c.A > 32 && (absDiff(c.R, blue.R) > 8 || absDiff(c.G, blue.G) > 8 || absDiff(c.B, blue.B) > 8) {
+					t.Fatalf("%s: halo pixel at (%d, %d): %v", name, x, y, c)
+				}
+			}
+		}
+		if name != "square" && partial == 0 {
+			t.Errorf("%s: expected anti-aliased edges", name)
+		}
+
+		// Over a colored page the light modules show the page
+		page := image.NewRGBA(bounds)
+		draw.Draw(page, bounds, &image.Uniform{color.RGBA{250, 230, 120, 255}}, image.Point{}, draw.Src)
+		draw.Draw(page, bounds, img, bounds.Min, draw.Over)
+		saveTestImage(t, page, "test_transparent_"+name+".png")
+	}
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
@@ -1,5 +1,7 @@
 package myqrcode
 
+import "fmt"
+
 // BlockInfo contains information about a group of blocks for a specific version and error correction level.
 type BlockInfo struct {
 	NumBlocks      int
@@ -79,6 +81,24 @@ func determineVersion(data string, mode EncodingMode, level ErrorCorrectionLevel
 	return 40 // Return max version if data is too large
 }
 
+// DataCapacity returns how many characters (bytes in Byte mode) fit in a
+// version at the given error correction level
+func DataCapacity(version int, mode EncodingMode, level ErrorCorrectionLevel) int {
+	return getDataCapacity(version, mode, level)
+}
+
+// MinimumVersion returns the smallest version that holds length characters
+// (bytes in Byte mode), or an error if the data exceeds version 40
+func MinimumVersion(length int, mode EncodingMode, level ErrorCorrectionLevel) (int, error) {
+	for _, info := range versionTable {
+		if length <= getDataCapacity(info.Version, mode, level) {
+			return info.Version, nil
+		}
+	}
+	return 0, fmt.Errorf("%d characters exceed the %s capacity of version 40 (%d)",
+		length, mode, getDataCapacity(40, mode, level))
+}
+
 func getDataCapacity(version int, mode EncodingMode, level ErrorCorrectionLevel) int {
 	if version < 1 || version > len(versionTable) {
 		return 0